@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
 	"regexp"
 	"strings"
@@ -31,14 +32,43 @@ type Decoder struct {
 	reader *bufio.Reader
 	atEOF  bool
 	state  decoderState
+
+	fileName string
+	line     int // number of lines consumed so far
+	entries  int // number of entries (headers) seen so far
 }
 
 func NewDecoder(reader io.Reader) *Decoder {
-	return &Decoder{
+	d := &Decoder{
 		reader: bufio.NewReader(reader),
 		atEOF:  false,
 		state:  idle,
 	}
+
+	// a nil *os.File is passed along with the error of a failed os.Open
+	if f, ok := reader.(*os.File); ok && f != nil {
+		d.fileName = f.Name()
+	}
+
+	return d
+}
+
+// SetFileName sets the input file name reported in [ParseError]s. It is detected automatically for [pkg/os.File]s.
+func (d *Decoder) SetFileName(name string) {
+	d.fileName = name
+}
+
+// fills in the position of the error, if it is a [ParseError]
+func (d *Decoder) positioned(err error) error {
+	if pe, ok := err.(*ParseError); ok {
+		pe.File = d.fileName
+		pe.Line = d.line
+		pe.Stanza = d.entries
+		if pe.Column == 0 {
+			pe.Column = 1
+		}
+	}
+	return err
 }
 
 func (d *Decoder) readAndDecodeStanza(entry *Entry) bool {
@@ -49,6 +79,9 @@ func (d *Decoder) readAndDecodeStanza(entry *Entry) bool {
 	body := ""
 	for {
 		line, err := d.reader.ReadString('\n')
+		if line != "" {
+			d.line++
+		}
 
 		if err == io.EOF /*&& line != ""*/ {
 			d.atEOF = true
@@ -62,13 +95,14 @@ func (d *Decoder) readAndDecodeStanza(entry *Entry) bool {
 
 		switch d.state {
 		case idle:
+			d.entries++
 			d.err = decodeHeader(line, entry)
 			d.state = doneHeader
 		case doneHeader:
 			if line == "\n" {
 				d.state = doneHeaderSeparator
 			} else {
-				d.err = fmt.Errorf("changelog format error: missing header separator")
+				d.err = &ParseError{Err: fmt.Errorf("changelog format error: missing header separator")}
 			}
 		case doneHeaderSeparator:
 			if !trailerRe.MatchString(line) {
@@ -85,12 +119,14 @@ func (d *Decoder) readAndDecodeStanza(entry *Entry) bool {
 			if line == "\n" || d.atEOF {
 				d.state = idle
 			} else {
-				d.err = fmt.Errorf("changelog format error: missing trailer separator")
+				d.err = &ParseError{Err: fmt.Errorf("changelog format error: missing trailer separator")}
 			}
+			d.err = d.positioned(d.err)
 			return !d.atEOF
 		}
 
 		if d.err != nil {
+			d.err = d.positioned(d.err)
 			return !d.atEOF
 		}
 	}
@@ -126,7 +162,7 @@ func (d *Decoder) Decode(res any) error {
 func decodeHeader(line string, entry *Entry) error {
 	if !headerRe.MatchString(line) {
 		entry = &Entry{}
-		return fmt.Errorf("changelog entry header format error")
+		return &ParseError{Field: "header", Err: fmt.Errorf("changelog entry header format error")}
 	}
 
 	headerMatches := headerRe.FindAllStringSubmatch(line, -1)[0]
@@ -146,7 +182,11 @@ func decodeTrailer(line string, entry *Entry) error {
 
 	if timestamp, err := time.Parse(time.RFC1123Z, trailerMatches[3]); err != nil {
 		entry = &Entry{}
-		return fmt.Errorf("changelog entry timestamp format error")
+		return &ParseError{
+			Column: strings.LastIndex(line, trailerMatches[3]) + 1,
+			Field:  "timestamp",
+			Err:    fmt.Errorf("changelog entry timestamp format error"),
+		}
 	} else {
 
 		entry.Timestamp = Timestamp(timestamp)
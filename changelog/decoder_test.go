@@ -1,8 +1,11 @@
 package changelog_test
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"testing"
 
 	"github.com/aol-nnov/debian/changelog"
 )
@@ -58,3 +61,54 @@ func ExampleDecoder_Decode_slice() {
 	//
 	//  -- Package Maintainer <pkg-maint@example.net>  Thu, 15 Dec 2022 17:27:01 +0000
 }
+
+func ExampleParseError() {
+	in := `pkg-name (3.2.16) next; urgency=medium
+
+  * Change
+
+ -- Package Maintainer <pkg-maint@example.net>  Mon, 19 Dec 2022 11:50:13 +0000
+
+pkg-name (3.2.15) next; urgency=medium
+  * Missing separator
+`
+	var entries []changelog.Entry
+	err := changelog.NewDecoder(strings.NewReader(in)).Decode(&entries)
+
+	var pe *changelog.ParseError
+	if errors.As(err, &pe) {
+		fmt.Println(pe)
+	}
+
+	// Output: <input>:8:1: entry 2: changelog format error: missing header separator
+}
+
+func TestParseErrorTimestamp(t *testing.T) {
+	in := `pkg-name (3.2.16) next; urgency=medium
+
+  * Change
+
+ -- Package Maintainer <pkg-maint@example.net>  yesterday
+`
+	var entry changelog.Entry
+	err := changelog.NewDecoder(strings.NewReader(in)).Decode(&entry)
+
+	var pe *changelog.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+
+	if pe.Line != 5 || pe.Column != 49 || pe.Field != "timestamp" || pe.Stanza != 1 {
+		t.Fatalf("wrong position %+v", pe)
+	}
+}
+
+func TestNewDecoderNilFile(t *testing.T) {
+	// os.Open failed, the error is handled later on
+	f, _ := os.Open("testdata/missing")
+
+	var e changelog.Entry
+	if err := changelog.NewDecoder(f).Decode(&e); err == nil {
+		t.Fatal("expected an error decoding from a nil file")
+	}
+}
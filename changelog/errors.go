@@ -0,0 +1,51 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ParseError is returned by [Decoder] for malformed changelog entries. It carries the position of the problem, so it can
+be reported in a compiler-like manner:
+
+	var pe *changelog.ParseError
+	if errors.As(err, &pe) {
+		fmt.Printf("%s:%d:%d: %v\n", pe.File, pe.Line, pe.Column, pe.Err)
+	}
+*/
+type ParseError struct {
+	File   string // input file name, empty if unknown
+	Line   int    // 1-based line number
+	Column int    // 1-based column number
+	Stanza int    // 1-based index of the changelog entry
+	Field  string // offending part of the entry (i.e. "header" or "timestamp"), empty if unknown
+	Err    error  // the actual error
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+
+	if e.File != "" {
+		sb.WriteString(e.File)
+	} else {
+		sb.WriteString("<input>")
+	}
+	fmt.Fprintf(&sb, ":%d:%d: ", e.Line, e.Column)
+
+	if e.Stanza > 0 {
+		fmt.Fprintf(&sb, "entry %d: ", e.Stanza)
+	}
+
+	if e.Field != "" {
+		fmt.Fprintf(&sb, "%s: ", e.Field)
+	}
+
+	sb.WriteString(e.Err.Error())
+
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
 	"github.com/aol-nnov/debian/deb822"
 )
 
@@ -27,21 +26,11 @@ type Changes struct {
 	Files        []ChangesFile     `delim:"\n" s_trip:" \n"`
 }
 
+// FromStream decodes .changes file. Clearsigned input is accepted, the signature is not verified
 func FromStream(r io.Reader) (*Changes, error) {
 	var c Changes
-	var buf bytes.Buffer
-	buf.ReadFrom(r)
-	block, plain := clearsign.Decode(buf.Bytes())
 
-	var changelog []byte
-
-	if block != nil {
-		changelog = block.Plaintext
-	} else {
-		changelog = plain
-	}
-
-	if err := deb822.NewDecoder(bytes.NewReader(changelog)).Decode(&c); err == nil {
+	if err := deb822.NewDecoder(r).Decode(&c); err == nil {
 		return &c, nil
 	} else {
 
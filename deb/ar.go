@@ -0,0 +1,136 @@
+package deb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ar(5) archive format, common variant used by .deb
+// https://manpages.debian.org/deb.5
+const (
+	arMagic      = "!<arch>\n"
+	arHeaderSize = 60
+)
+
+// header of an ar archive member
+type arHeader struct {
+	Name string
+	Size int64
+	Mode int64
+}
+
+// sequential reader of ar archive members
+type arReader struct {
+	r       *bufio.Reader
+	current io.Reader // body of the current member
+	pad     int64     // members are aligned to even offsets
+	started bool
+}
+
+func newArReader(r io.Reader) *arReader {
+	return &arReader{r: bufio.NewReader(r)}
+}
+
+// Next advances to the next member. io.EOF is returned at the end of the archive
+func (ar *arReader) Next() (*arHeader, error) {
+	if !ar.started {
+		magic := make([]byte, len(arMagic))
+		if _, err := io.ReadFull(ar.r, magic); err != nil || string(magic) != arMagic {
+			return nil, errors.New("not an ar archive")
+		}
+		ar.started = true
+	}
+
+	// skip unread part of the previous member
+	if ar.current != nil {
+		if _, err := io.Copy(io.Discard, ar); err != nil {
+			return nil, err
+		}
+		if _, err := ar.r.Discard(int(ar.pad)); err != nil && err != io.EOF {
+			return nil, err
+		}
+		ar.current = nil
+	}
+
+	buf := make([]byte, arHeaderSize)
+	if _, err := io.ReadFull(ar.r, buf); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("truncated ar member header: %w", err)
+	}
+
+	if string(buf[58:60]) != "`\n" {
+		return nil, errors.New("malformed ar member header")
+	}
+
+	// GNU ar terminates names with a slash
+	hdr := arHeader{Name: strings.TrimSuffix(strings.TrimRight(string(buf[0:16]), " "), "/")}
+
+	var err error
+	if hdr.Size, err = strconv.ParseInt(strings.TrimRight(string(buf[48:58]), " "), 10, 64); err != nil || hdr.Size < 0 {
+		return nil, fmt.Errorf("ar member %s: malformed size", hdr.Name)
+	}
+	if hdr.Mode, err = strconv.ParseInt(strings.TrimRight(string(buf[40:48]), " "), 8, 64); err != nil {
+		return nil, fmt.Errorf("ar member %s: malformed mode", hdr.Name)
+	}
+
+	ar.current = io.LimitReader(ar.r, hdr.Size)
+	ar.pad = hdr.Size % 2
+
+	return &hdr, nil
+}
+
+// Read reads the body of the current member
+func (ar *arReader) Read(p []byte) (int, error) {
+	if ar.current == nil {
+		return 0, io.EOF
+	}
+
+	n, err := ar.current.Read(p)
+	if err == io.EOF {
+		if lr := ar.current.(*io.LimitedReader); lr.N > 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+	}
+	return n, err
+}
+
+// sequential writer of ar archive members
+type arWriter struct {
+	w       io.Writer
+	started bool
+}
+
+// WriteMember writes a member with root ownership, mode 0644 and modification time mtime
+func (ar *arWriter) WriteMember(name string, mtime int64, body []byte) error {
+	if !ar.started {
+		if _, err := io.WriteString(ar.w, arMagic); err != nil {
+			return err
+		}
+		ar.started = true
+	}
+
+	if len(name) > 16 {
+		return fmt.Errorf("ar member name %s is too long", name)
+	}
+
+	hdr := fmt.Sprintf("%-16s%-12d%-6d%-6d%-8o%-10d`\n", name, mtime, 0, 0, 0100644, len(body))
+	if _, err := io.WriteString(ar.w, hdr); err != nil {
+		return err
+	}
+
+	if _, err := ar.w.Write(body); err != nil {
+		return err
+	}
+
+	if len(body)%2 != 0 {
+		_, err := io.WriteString(ar.w, "\n")
+		return err
+	}
+	return nil
+}
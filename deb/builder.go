@@ -0,0 +1,368 @@
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aol-nnov/debian/deb822"
+	"github.com/aol-nnov/debian/pkg"
+	"github.com/mholt/archives"
+)
+
+// Compression of control.tar and data.tar members
+type Compression int
+
+const (
+	CompressionXz Compression = iota // the default, as dpkg-deb does
+	CompressionGzip
+	CompressionZstd
+	CompressionNone
+)
+
+// suffix of the member name and the compressor, nil for no compression
+func (c Compression) format() (string, archives.Compressor) {
+	switch c {
+	case CompressionGzip:
+		return ".gz", archives.Gz{}
+	case CompressionZstd:
+		return ".zst", archives.Zstd{}
+	case CompressionNone:
+		return "", nil
+	default:
+		return ".xz", archives.Xz{}
+	}
+}
+
+// file of the data archive to be built
+type builderEntry struct {
+	name    string // without leading "./", directories end with a slash
+	mode    fs.FileMode
+	target  string // symlink target
+	content []byte
+	src     string // path to read content from, if it is not in memory
+	size    int64
+}
+
+/*
+Builder builds reproducible .deb packages, like dpkg-deb --build --root-owner-group does:
+
+  - entries of both archives are sorted by name;
+  - every entry is owned by root:root and has modification time ModTime;
+  - parent directories are added automatically with mode 0755;
+  - md5sums and Installed-Size are computed.
+
+Compressed archives are kept in memory, as ar(5) member headers need their sizes.
+*/
+type Builder struct {
+	Control     pkg.BinaryPackage
+	Compression Compression
+	ModTime     time.Time
+
+	// control archive members except for control and md5sums, i.e. postinst or conffiles
+	ControlFiles map[string][]byte
+
+	entries map[string]builderEntry
+}
+
+/*
+NewBuilder returns builder of the package described by control.
+
+ModTime is taken from SOURCE_DATE_EPOCH environment variable, see https://reproducible-builds.org/specs/source-date-epoch/.
+If it is not set, the Unix epoch is used.
+*/
+func NewBuilder(control pkg.BinaryPackage) (*Builder, error) {
+	res := Builder{
+		Control:      control,
+		ModTime:      time.Unix(0, 0),
+		ControlFiles: make(map[string][]byte),
+		entries:      make(map[string]builderEntry),
+	}
+
+	if epoch, found := os.LookupEnv("SOURCE_DATE_EPOCH"); found {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed SOURCE_DATE_EPOCH: %w", err)
+		}
+		res.ModTime = time.Unix(seconds, 0)
+	}
+
+	return &res, nil
+}
+
+func (b *Builder) add(e builderEntry) {
+	e.name = strings.TrimLeft(path.Clean("/"+e.name), "/")
+	if e.mode.IsDir() {
+		e.name += "/"
+	}
+
+	b.entries[e.name] = e
+
+	for dir := path.Dir(strings.TrimSuffix(e.name, "/")); dir != "."; dir = path.Dir(dir) {
+		if _, found := b.entries[dir+"/"]; !found {
+			b.entries[dir+"/"] = builderEntry{name: dir + "/", mode: fs.ModeDir | 0755}
+		}
+	}
+}
+
+// permission bits of a regular file, including setuid, setgid and sticky ones
+func fileMode(mode fs.FileMode) fs.FileMode {
+	return mode & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+}
+
+// AddFile adds a regular file with contents content. Only permission bits of mode are used
+func (b *Builder) AddFile(name string, mode fs.FileMode, content []byte) {
+	b.add(builderEntry{name: name, mode: fileMode(mode), content: content, size: int64(len(content))})
+}
+
+// AddDir adds a directory
+func (b *Builder) AddDir(name string, mode fs.FileMode) {
+	b.add(builderEntry{name: name, mode: fs.ModeDir | mode.Perm()})
+}
+
+// AddSymlink adds a symbolic link
+func (b *Builder) AddSymlink(name, target string) {
+	b.add(builderEntry{name: name, mode: fs.ModeSymlink | 0777, target: target})
+}
+
+/*
+AddTree adds contents of the staging directory root. DEBIAN subdirectory, if present, provides control archive
+members, except for control and md5sums, which are generated. Regular files in subdirectories of DEBIAN are refused.
+Contents of files are read at build time.
+
+Only regular files, directories and symbolic links are supported.
+*/
+func (b *Builder) AddTree(root string) error {
+	return filepath.WalkDir(root, func(src string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(root, src)
+		if err != nil || name == "." {
+			return err
+		}
+		name = filepath.ToSlash(name)
+
+		if name == "DEBIAN" || strings.HasPrefix(name, "DEBIAN/") {
+			// control archive is flat, like dpkg-deb, refuse files it would not find
+			if d.Type().IsRegular() && path.Dir(name) != "DEBIAN" {
+				return fmt.Errorf("%s: control files must not reside in subdirectories of DEBIAN", src)
+			}
+
+			if d.Type().IsRegular() && name != "DEBIAN/control" && name != "DEBIAN/md5sums" {
+				content, err := os.ReadFile(src)
+				if err != nil {
+					return err
+				}
+				b.ControlFiles[path.Base(name)] = content
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			b.AddDir(name, info.Mode())
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(src)
+			if err != nil {
+				return err
+			}
+			b.AddSymlink(name, target)
+		case d.Type().IsRegular():
+			b.add(builderEntry{name: name, mode: fileMode(info.Mode()), src: src, size: info.Size()})
+		default:
+			return fmt.Errorf("%s: unsupported file type %s", src, info.Mode().Type())
+		}
+
+		return nil
+	})
+}
+
+// Build writes the package to w
+func (b *Builder) Build(w io.Writer) error {
+	names := slices.Sorted(maps.Keys(b.entries))
+
+	var md5sums bytes.Buffer
+	installedSize := int64(0)
+
+	data, err := b.archive(func(tw *tar.Writer) error {
+		if err := tw.WriteHeader(b.header("./", fs.ModeDir|0755, 0, "")); err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			e := b.entries[name]
+
+			if err := tw.WriteHeader(b.header("./"+name, e.mode, e.size, e.target)); err != nil {
+				return err
+			}
+
+			if !e.mode.IsRegular() {
+				installedSize++
+				continue
+			}
+
+			content := e.content
+			if e.src != "" {
+				var err error
+				if content, err = os.ReadFile(e.src); err != nil {
+					return err
+				}
+				if int64(len(content)) != e.size {
+					return fmt.Errorf("%s: file changed during build", e.src)
+				}
+			}
+
+			if _, err := tw.Write(content); err != nil {
+				return err
+			}
+
+			sum := md5.Sum(content)
+			fmt.Fprintf(&md5sums, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+			installedSize += (e.size + 1023) / 1024
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("data archive: %w", err)
+	}
+
+	control := b.Control
+	control.InstalledSize = int(installedSize)
+
+	var controlFile bytes.Buffer
+	if err := deb822.NewEncoder(&controlFile).Encode(control); err != nil {
+		return fmt.Errorf("control: %w", err)
+	}
+
+	controlFiles := map[string][]byte{"control": controlFile.Bytes()}
+	if md5sums.Len() > 0 {
+		controlFiles["md5sums"] = md5sums.Bytes()
+	}
+	for name, content := range b.ControlFiles {
+		if name != "control" && name != "md5sums" {
+			controlFiles[name] = content
+		}
+	}
+
+	controlArchive, err := b.archive(func(tw *tar.Writer) error {
+		if err := tw.WriteHeader(b.header("./", fs.ModeDir|0755, 0, "")); err != nil {
+			return err
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(controlFiles)) {
+			mode := fs.FileMode(0644)
+			if slices.Contains(MaintainerScripts, name) {
+				mode = 0755
+			}
+
+			if err := tw.WriteHeader(b.header("./"+name, mode, int64(len(controlFiles[name])), "")); err != nil {
+				return err
+			}
+			if _, err := tw.Write(controlFiles[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("control archive: %w", err)
+	}
+
+	suffix, _ := b.Compression.format()
+	ar := arWriter{w: w}
+	mtime := b.ModTime.Unix()
+
+	if err := ar.WriteMember("debian-binary", mtime, []byte("2.0\n")); err != nil {
+		return err
+	}
+	if err := ar.WriteMember("control.tar"+suffix, mtime, controlArchive); err != nil {
+		return err
+	}
+	return ar.WriteMember("data.tar"+suffix, mtime, data)
+}
+
+func (b *Builder) header(name string, mode fs.FileMode, size int64, target string) *tar.Header {
+	hdr := tar.Header{
+		Name:    name,
+		Mode:    int64(mode.Perm()),
+		ModTime: b.ModTime,
+		Uname:   "root",
+		Gname:   "root",
+		Format:  tar.FormatGNU,
+	}
+
+	if mode&fs.ModeSetuid != 0 {
+		hdr.Mode |= 04000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		hdr.Mode |= 02000
+	}
+	if mode&fs.ModeSticky != 0 {
+		hdr.Mode |= 01000
+	}
+
+	switch {
+	case mode.IsDir():
+		hdr.Typeflag = tar.TypeDir
+	case mode&fs.ModeSymlink != 0:
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = target
+	default:
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = size
+	}
+
+	return &hdr
+}
+
+// writes tar archive with fill and compresses it
+func (b *Builder) archive(fill func(tw *tar.Writer) error) ([]byte, error) {
+	var res bytes.Buffer
+
+	var w io.Writer = &res
+	_, compressor := b.Compression.format()
+
+	var cw io.WriteCloser
+	if compressor != nil {
+		var err error
+		if cw, err = compressor.OpenWriter(&res); err != nil {
+			return nil, err
+		}
+		w = cw
+	}
+
+	tw := tar.NewWriter(w)
+	if err := fill(tw); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	if cw != nil {
+		if err := cw.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return res.Bytes(), nil
+}
@@ -0,0 +1,162 @@
+package deb_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/deb"
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/pkg"
+)
+
+func control() pkg.BinaryPackage {
+	var dep fields.Dependency
+	dep.UnmarshalText([]byte("libc6 (>= 2.34)"))
+
+	return pkg.BinaryPackage{
+		Name:         "hello",
+		Version:      fields.MakeVersion("1.0-1"),
+		Architecture: fields.MakeArch("amd64"),
+		Maintainer:   "John Doe <john@example.org>",
+		Depends:      fields.Dependencies{dep},
+		Description:  "example package\nThe package is used to test .deb builder.",
+	}
+}
+
+func TestBuilderRoundTrip(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	for _, compression := range []deb.Compression{deb.CompressionXz, deb.CompressionGzip, deb.CompressionZstd, deb.CompressionNone} {
+		var builds [2]bytes.Buffer
+		for idx := range builds {
+			b, err := deb.NewBuilder(control())
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.Compression = compression
+			b.ControlFiles["postinst"] = []byte("#!/bin/sh\nset -e\n")
+
+			// order of additions does not matter
+			if idx == 0 {
+				b.AddFile("usr/bin/hello", 0755, []byte("#!/bin/sh\necho hello\n"))
+				b.AddSymlink("/usr/bin/hi", "hello")
+			} else {
+				b.AddSymlink("/usr/bin/hi", "hello")
+				b.AddFile("./usr/bin/hello", 0755, []byte("#!/bin/sh\necho hello\n"))
+			}
+
+			if err := b.Build(&builds[idx]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(builds[0].Bytes(), builds[1].Bytes()) {
+			t.Fatalf("compression %d: build is not reproducible", compression)
+		}
+
+		r, err := deb.NewReader(&builds[0])
+		if err != nil {
+			t.Fatal(compression, err)
+		}
+
+		if r.Control.InstalledSize != 4 || r.Control.Description != control().Description {
+			t.Fatalf("compression %d: unexpected control %+v", compression, r.Control)
+		}
+
+		if sums, err := r.Md5sums(); err != nil || len(sums) != 1 || sums["usr/bin/hello"] == "" {
+			t.Fatalf("compression %d: unexpected md5sums %v (%v)", compression, sums, err)
+		}
+
+		if _, found := r.Scripts()["postinst"]; !found {
+			t.Fatalf("compression %d: postinst is missing", compression)
+		}
+
+		var names []string
+		for f, err := range r.Files() {
+			if err != nil {
+				t.Fatal(compression, err)
+			}
+			if f.Owner != "root" || f.ModTime.Unix() != 1700000000 {
+				t.Fatalf("compression %d: unexpected entry %+v", compression, f)
+			}
+			names = append(names, f.Name)
+		}
+
+		if len(names) != 4 || names[0] != "usr/" || names[3] != "usr/bin/hi" {
+			t.Fatalf("compression %d: unexpected entries %v", compression, names)
+		}
+	}
+}
+
+func TestBuilderTree(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "DEBIAN"), 0755)
+	os.MkdirAll(filepath.Join(root, "etc/hello"), 0755)
+	os.WriteFile(filepath.Join(root, "DEBIAN/control"), []byte("ignored"), 0644)
+	os.WriteFile(filepath.Join(root, "DEBIAN/conffiles"), []byte("/etc/hello/hello.conf\n"), 0644)
+	os.WriteFile(filepath.Join(root, "etc/hello/hello.conf"), bytes.Repeat([]byte("x"), 1500), 0644)
+
+	b, err := deb.NewBuilder(control())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddTree(root); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := b.Build(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := deb.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conffiles := r.Conffiles(); len(conffiles) != 1 || r.Control.InstalledSize != 4 || r.Control.Name != "hello" {
+		t.Fatalf("unexpected package %v %+v", conffiles, r.Control)
+	}
+
+	count := 0
+	for f, err := range r.Files() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == "DEBIAN/" {
+			t.Fatal("DEBIAN must not be a part of the data archive")
+		}
+		count++
+	}
+
+	if count != 3 {
+		t.Fatalf("unexpected number of entries %d", count)
+	}
+}
+
+func TestBuilderSourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+
+	if _, err := deb.NewBuilder(control()); err == nil {
+		t.Fatal("malformed SOURCE_DATE_EPOCH must fail")
+	}
+}
+
+func TestBuilderTreeNestedControl(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "DEBIAN/extra"), 0755)
+	os.WriteFile(filepath.Join(root, "DEBIAN/postinst"), []byte("#!/bin/sh\n"), 0755)
+	os.WriteFile(filepath.Join(root, "DEBIAN/extra/postinst"), []byte("#!/bin/sh\nrm -rf /\n"), 0755)
+
+	b, err := deb.NewBuilder(control())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.AddTree(root); err == nil || !strings.Contains(err.Error(), "subdirectories of DEBIAN") {
+		t.Fatalf("nested control file must be refused, got %v", err)
+	}
+}
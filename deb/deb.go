@@ -0,0 +1,299 @@
+/*
+Package deb reads and builds Debian binary packages (.deb), see deb(5).
+
+	f, _ := os.Open("hello_1.0-1_amd64.deb")
+	defer f.Close()
+
+	r, err := deb.NewReader(f)
+	...
+	fmt.Println(r.Control.Name, r.Control.Version)
+
+	for file, err := range r.Files() {
+		...
+	}
+
+Control archive is read eagerly, as it is small. Data archive is streamed with [Reader.Files], so the package is read
+only once and may come from a pipe or a network connection.
+
+Packages are built with [Builder].
+*/
+package deb
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aol-nnov/debian/deb822"
+	"github.com/aol-nnov/debian/internal/universalreader"
+	"github.com/aol-nnov/debian/pkg"
+)
+
+// MaintainerScripts are the control archive members dpkg runs during package installation and removal
+var MaintainerScripts = []string{"preinst", "postinst", "prerm", "postrm", "config"}
+
+// compression suffixes of control.tar and data.tar members dpkg supports
+var (
+	controlSuffixes = []string{"", ".gz", ".xz", ".zst"}
+	dataSuffixes    = []string{"", ".gz", ".xz", ".zst", ".bz2", ".lzma"}
+)
+
+// Reader reads a .deb package
+type Reader struct {
+	Format  string // contents of debian-binary, i.e. "2.0"
+	Control pkg.BinaryPackage
+
+	controlFiles map[string][]byte
+	ar           *arReader
+	filesRead    bool
+}
+
+// File is an entry of the data archive
+type File struct {
+	Name       string // path without leading "./", directories end with a slash
+	Mode       fs.FileMode
+	Size       int64
+	Uid, Gid   int
+	Owner      string
+	Group      string
+	LinkTarget string // target of a symlink or a hardlink
+	ModTime    time.Time
+
+	content io.Reader
+}
+
+// Read reads contents of a regular file. It is valid only until the next iteration of [Reader.Files]
+func (f File) Read(p []byte) (int, error) {
+	if f.content == nil {
+		return 0, io.EOF
+	}
+	return f.content.Read(p)
+}
+
+/*
+NewReader reads debian-binary and control archive members of the package. Data archive is left for [Reader.Files].
+
+Supported format is 2.x. Control archive may be compressed with gzip, xz or zstd.
+*/
+func NewReader(r io.Reader) (*Reader, error) {
+	res := Reader{ar: newArReader(r), controlFiles: make(map[string][]byte)}
+
+	hdr, err := res.next()
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Name != "debian-binary" {
+		return nil, fmt.Errorf("first member must be debian-binary, got %s", hdr.Name)
+	}
+
+	format, err := io.ReadAll(res.ar)
+	if err != nil {
+		return nil, err
+	}
+	res.Format = strings.TrimSpace(string(format))
+	if !strings.HasPrefix(res.Format, "2.") {
+		return nil, fmt.Errorf("unsupported package format %s", res.Format)
+	}
+
+	if hdr, err = res.next(); err != nil {
+		return nil, err
+	}
+	if !memberMatches(hdr.Name, "control.tar", controlSuffixes) {
+		return nil, fmt.Errorf("second member must be control.tar, got %s", hdr.Name)
+	}
+
+	if err := res.readControlArchive(hdr.Name); err != nil {
+		return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+	}
+
+	control, found := res.controlFiles["control"]
+	if !found {
+		return nil, errors.New("control file is missing")
+	}
+	if err := deb822.NewDecoder(bytes.NewReader(control)).Decode(&res.Control); err != nil {
+		return nil, fmt.Errorf("control: %w", err)
+	}
+
+	return &res, nil
+}
+
+// returns the next member, skipping the ones starting with underscore, which are reserved for extensions
+func (r *Reader) next() (*arHeader, error) {
+	for {
+		hdr, err := r.ar.Next()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil || !strings.HasPrefix(hdr.Name, "_") {
+			return hdr, err
+		}
+	}
+}
+
+func memberMatches(name, base string, suffixes []string) bool {
+	suffix, found := strings.CutPrefix(name, base)
+	return found && slices.Contains(suffixes, suffix)
+}
+
+// returns decompressing reader of the current member. Uncompressed tar is passed as is, as it is not a compression format
+func (r *Reader) decompress(name string) (io.Reader, error) {
+	if path.Ext(name) == ".tar" {
+		return r.ar, nil
+	}
+	return universalreader.Decompress(r.ar)
+}
+
+func (r *Reader) readControlArchive(name string) error {
+	decompressed, err := r.decompress(name)
+	if err != nil {
+		return err
+	}
+	defer universalreader.MaybeClose(decompressed)
+
+	tr := tar.NewReader(decompressed)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		r.controlFiles[path.Clean(strings.TrimPrefix(hdr.Name, "./"))] = content
+	}
+}
+
+// ControlFile returns raw contents of a control archive member, i.e. "shlibs", "triggers" or "templates"
+func (r *Reader) ControlFile(name string) ([]byte, bool) {
+	content, found := r.controlFiles[name]
+	return content, found
+}
+
+// Scripts returns maintainer scripts found in the package by name
+func (r *Reader) Scripts() map[string][]byte {
+	res := make(map[string][]byte)
+	for _, name := range MaintainerScripts {
+		if script, found := r.controlFiles[name]; found {
+			res[name] = script
+		}
+	}
+	return res
+}
+
+// Md5sums returns MD5 checksums of files by path without leading slash, as listed in md5sums control file
+func (r *Reader) Md5sums() (map[string]string, error) {
+	res := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(r.controlFiles["md5sums"]))
+	for line := 1; scanner.Scan(); line++ {
+		if scanner.Text() == "" {
+			continue
+		}
+
+		sum, name, found := strings.Cut(scanner.Text(), " ")
+		if !found || len(sum) != 32 {
+			return nil, fmt.Errorf("md5sums: malformed line %d", line)
+		}
+		res[strings.TrimLeft(name, " *")] = sum
+	}
+
+	return res, scanner.Err()
+}
+
+// Conffiles returns lines of conffiles control file: absolute paths, optionally prefixed with flags, like
+// remove-on-upgrade
+func (r *Reader) Conffiles() []string {
+	var res []string
+	for _, line := range strings.Split(string(r.controlFiles["conffiles"]), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+/*
+Files returns an iterator over entries of the data archive. The archive is streamed, so it may be iterated only once.
+
+Contents of a regular file may be read from [File] until the iteration proceeds.
+*/
+func (r *Reader) Files() iter.Seq2[File, error] {
+	return func(yield func(File, error) bool) {
+		if r.filesRead {
+			yield(File{}, errors.New("data archive has been read already"))
+			return
+		}
+		r.filesRead = true
+
+		hdr, err := r.next()
+		if err != nil {
+			yield(File{}, err)
+			return
+		}
+		if !memberMatches(hdr.Name, "data.tar", dataSuffixes) {
+			yield(File{}, fmt.Errorf("third member must be data.tar, got %s", hdr.Name))
+			return
+		}
+
+		decompressed, err := r.decompress(hdr.Name)
+		if err != nil {
+			yield(File{}, fmt.Errorf("%s: %w", hdr.Name, err))
+			return
+		}
+		defer universalreader.MaybeClose(decompressed)
+
+		tr := tar.NewReader(decompressed)
+		for {
+			th, err := tr.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(File{}, fmt.Errorf("%s: %w", hdr.Name, err))
+				return
+			}
+
+			f := File{
+				Name:       strings.TrimPrefix(th.Name, "./"),
+				Mode:       th.FileInfo().Mode(),
+				Size:       th.Size,
+				Uid:        th.Uid,
+				Gid:        th.Gid,
+				Owner:      th.Uname,
+				Group:      th.Gname,
+				LinkTarget: th.Linkname,
+				ModTime:    th.ModTime,
+			}
+			if th.Typeflag == tar.TypeReg {
+				f.content = tr
+			}
+
+			if f.Name == "" {
+				// root directory
+				continue
+			}
+
+			if !yield(f, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package deb_test
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/deb"
+)
+
+func ExampleReader_Files() {
+	f, _ := os.Open("testdata/hello_1.0-1_amd64.deb")
+	defer f.Close()
+
+	r, err := deb.NewReader(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(r.Control.Name, r.Control.Version, r.Control.Architecture, r.Control.InstalledSize)
+
+	for file, err := range r.Files() {
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if file.LinkTarget != "" {
+			fmt.Println(file.Mode, file.Owner, file.Group, file.Name, "->", file.LinkTarget)
+			continue
+		}
+		fmt.Println(file.Mode, file.Owner, file.Group, file.Name)
+	}
+
+	// Output:
+	// hello 1.0-1 amd64 8
+	// drwxr-xr-x root root etc/
+	// drwxr-xr-x root root etc/hello/
+	// -rw-r--r-- root root etc/hello/hello.conf
+	// drwxr-xr-x root root usr/
+	// drwxr-xr-x root root usr/bin/
+	// -rwxr-xr-x root root usr/bin/hello
+	// drwxr-xr-x root root usr/share/
+	// drwxr-xr-x root root usr/share/doc/
+	// drwxr-xr-x root root usr/share/doc/hello/
+	// -rw-r--r-- root root usr/share/doc/hello/changelog
+	// Lrwxrwxrwx root root usr/bin/hi -> hello
+}
+
+func TestReaderControlFiles(t *testing.T) {
+	for _, name := range []string{"testdata/hello_1.0-1_amd64.deb", "testdata/hello-zstd_1.0-1_amd64.deb"} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		r, err := deb.NewReader(f)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		if r.Format != "2.0" || r.Control.Maintainer != "John Doe <john@example.org>" {
+			t.Fatalf("%s: unexpected control %+v", name, r.Control)
+		}
+
+		if conffiles := r.Conffiles(); len(conffiles) != 1 || conffiles[0] != "/etc/hello/hello.conf" {
+			t.Fatalf("%s: unexpected conffiles %v", name, conffiles)
+		}
+
+		if scripts := r.Scripts(); len(scripts) != 1 || !strings.Contains(string(scripts["postinst"]), "configured") {
+			t.Fatalf("%s: unexpected scripts %v", name, scripts)
+		}
+
+		sums, err := r.Md5sums()
+		if err != nil || len(sums) != 3 {
+			t.Fatalf("%s: unexpected md5sums %v (%v)", name, sums, err)
+		}
+
+		// contents of every regular file must match md5sums
+		checked := 0
+		for file, err := range r.Files() {
+			if err != nil {
+				t.Fatal(name, err)
+			}
+			if !file.Mode.IsRegular() {
+				continue
+			}
+
+			h := md5.New()
+			if _, err := io.Copy(h, file); err != nil {
+				t.Fatal(name, err)
+			}
+			if sums[file.Name] != hex.EncodeToString(h.Sum(nil)) {
+				t.Fatalf("%s: checksum mismatch for %s", name, file.Name)
+			}
+			checked++
+		}
+
+		if checked != 3 {
+			t.Fatalf("%s: %d files checked", name, checked)
+		}
+
+		for _, err := range r.Files() {
+			if err == nil {
+				t.Fatal("data archive must be read only once")
+			}
+		}
+	}
+}
+
+func TestReaderMalformed(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"!<arch>\n",
+		"!<arch>\ndebian-binary   0           0     0     100644  4         `\n3.0\n",
+		"!<arch>\ncontrol.tar     0           0     0     100644  4         `\n2.0\n",
+	} {
+		if _, err := deb.NewReader(strings.NewReader(in)); err == nil {
+			t.Fatalf("%q must fail", in)
+		}
+	}
+}
@@ -11,6 +11,32 @@ func decodeStruct(s stanza, into reflect.Value) error {
 		return decodeStruct(s, into.Elem())
 	}
 
+	decoded := make(map[string]bool, len(s))
+	var extra reflect.Value
+
+	if err := decodeStructFields(s, into, decoded, &extra); err != nil {
+		return err
+	}
+
+	// collect the leftovers
+	if extra.IsValid() {
+		for key, field := range s {
+			if decoded[key] {
+				continue
+			}
+
+			if extra.IsNil() {
+				extra.Set(reflect.MakeMap(extra.Type()))
+			}
+			extra.SetMapIndex(reflect.ValueOf(field.name), reflect.ValueOf(field.value))
+		}
+	}
+
+	return nil
+}
+
+// decodes fields of into (and embedded structs) from s, marking the decoded stanza keys
+func decodeStructFields(s stanza, into reflect.Value, decoded map[string]bool, extra *reflect.Value) error {
 	// Right, now, we're going to decode a [stanza] into the struct
 	// fmt.Printf("%s has %d fields\n", into.Type().Name(), into.NumField())
 	for i := 0; i < into.NumField(); i++ {
@@ -18,16 +44,21 @@ func decodeStruct(s stanza, into reflect.Value) error {
 		field := into.Field(i)
 		fieldType := into.Type().Field(i)
 
-		if field.Type().Kind() == reflect.Struct {
-			err := decodeStruct(s, field)
-			if err != nil {
+		if fieldType.Anonymous && field.Type().Kind() == reflect.Struct {
+			if err := decodeStructFields(s, field, decoded, extra); err != nil {
 				return err
 			}
+			continue
+		}
+
+		if !fieldType.IsExported() {
+			continue
 		}
 
 		// Get the name of the field as we'd index into the [stanza]
 		fieldName := fieldType.Name
-		if name, _ := parseTag(fieldType.Tag.Get("deb822")); name != "" {
+		name, opts := parseTag(fieldType.Tag.Get("deb822"))
+		if name != "" {
 			fieldName = name
 		}
 
@@ -36,39 +67,48 @@ func decodeStruct(s stanza, into reflect.Value) error {
 			continue
 		}
 
-		if value, ok := s[fieldName]; ok {
-			if err := decodeStructValue(field, fieldType, value); err != nil {
-				return err
+		if opts.Contain("extra") {
+			if fieldType.Type != reflect.TypeFor[map[string]string]() {
+				return fmt.Errorf("%s: extra field '%s' must be map[string]string", into.Type().Name(), fieldType.Name)
 			}
+			*extra = field
 			continue
-		} else {
-			if fieldType.Tag.Get("required") == "true" {
-				return fmt.Errorf(
-					"%s: required field '%s' is missing",
-					into.Type().Name(),
-					fieldType.Name,
-				)
-			}
+		}
 
+		if f, ok := s[stanzaKey(fieldName)]; ok {
+			decoded[stanzaKey(fieldName)] = true
+			if err := decodeStructValue(field, fieldType, f.value); err != nil {
+				return &fieldError{fieldName, err}
+			}
+			continue
+		} else {
 			// check alternate field value
 			if alias := fieldType.Tag.Get("if_missing"); alias != "" {
-				if value, ok := s[alias]; ok {
+				if f, ok := s[stanzaKey(alias)]; ok {
 					//fmt.Printf("value from stanza '%s'\n", value)
-					if err := decodeStructValue(field, fieldType, value); err != nil {
-						return err
-					}
-				} else {
-					// if alias field is also missing and current field is marked as required, bail out with error
-					if fieldType.Tag.Get("required") == "true" {
-						return fmt.Errorf(
-							"%s: required field '%s' is missing, alias %s is missing too",
-							into.Type().Name(),
-							fieldType.Name,
-							alias,
-						)
+					if err := decodeStructValue(field, fieldType, f.value); err != nil {
+						return &fieldError{alias, err}
 					}
+					continue
+				}
+
+				// if alias field is also missing and current field is marked as required, bail out with error
+				if fieldType.Tag.Get("required") == "true" {
+					return &fieldError{fieldName, fmt.Errorf(
+						"%s: required field is missing, alias %s is missing too",
+						into.Type().Name(),
+						alias,
+					)}
 				}
 			}
+
+			if fieldType.Tag.Get("required") == "true" {
+				return &fieldError{fieldName, fmt.Errorf(
+					"%s: required field is missing",
+					into.Type().Name(),
+				)}
+			}
+
 			// TODO(aol): is this diagnostic useful at all?
 			// if fieldType.Tag.Get("recommended") == "true" {
 			// 	fmt.Printf(
@@ -1,12 +1,18 @@
 package deb822
 
 import (
+	"encoding"
 	"fmt"
 	"reflect"
 	"strconv"
 )
 
 func decodeStructValue(field reflect.Value, fieldType reflect.StructField, value string) error {
+	// custom types of any kind (i.e. enums based on int) may implement their own parsing
+	if unmarshal, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshal.UnmarshalText([]byte(value))
+	}
+
 	switch field.Type().Kind() {
 	case reflect.String:
 		field.SetString(value)
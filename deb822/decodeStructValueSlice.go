@@ -20,6 +20,9 @@ func decodeStructValueSlice(field reflect.Value, fieldType reflect.StructField,
 
 	for _, el := range strings.Split(strings.Trim(value, strip), delim) {
 		el = strings.Trim(el, strip)
+		if el == "" {
+			continue
+		}
 
 		targetValue := reflect.New(underlyingType)
 
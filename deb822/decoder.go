@@ -5,6 +5,16 @@ Deb822 (https://manpages.debian.org/unstable/dpkg-dev/deb822.5.en.html) is based
 
 This decoder follows `encoding` package principles. It also supports `TextUnmarshaler` interface for custom types.
 
+Big streams (i.e. Packages or Sources indices) are better consumed with [Iter], which decodes one stanza at a time.
+
+To edit a file in place, decode it into [Paragraph]s, which keep the source text intact.
+
+OpenPGP clearsigned input (i.e. InRelease, signed .dsc or .changes files) is unwrapped transparently, see
+[Decoder.Signature].
+
+Field names are case-insensitive, as per deb822(5): `Build-Depends`, `build-depends` and `Build-depends` are the same
+field. It also applies to field names in struct tags.
+
 Following struct tags are supported:
 
   - `deb822:"field-name"` - denotes debian control field name to be decoded into the struct field. Use minus sign to ignore the field;
@@ -15,27 +25,57 @@ Following struct tags are supported:
 
   - `strip:"cutset"` - while decoding to a slice, removes all leading and trailing Unicode code points contained in `cutset` first hand. See [pkg/strings.Trim] for more info. We do not strip anything by default.;
 
-  - `delim:"sep"` - while decoding to a slice, substrings (future slice elements) are separated by `sep`. See [pkg/strings.Split] for more info. Default delimiter is " " (single space).
+  - `delim:"sep"` - while decoding to a slice, substrings (future slice elements) are separated by `sep`. See [pkg/strings.Split] for more info. Default delimiter is " " (single space). Empty elements (i.e. after a trailing comma) are skipped;
+
+  - `deb822:",extra"` - a map[string]string field, which collects all fields not decoded into other struct fields, keyed by their names as spelled in the source.
+
+Types implementing [pkg/encoding.TextUnmarshaler] are decoded with it, whatever their kind is. Embedded structs are
+decoded from the same stanza as the outer struct.
 */
 package deb822
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"os"
 	"reflect"
 	"strings"
-	"unicode"
 )
 
-type stanza map[string]string
+// single field of a [stanza]
+type stanzaField struct {
+	name  string // as spelled in the source
+	value string
+}
+
+// fields keyed by lower-cased field names, see [stanzaKey]
+type stanza map[string]stanzaField
+
+// Field names are case-insensitive, so stanza is keyed by their canonical (lower case) form
+func stanzaKey(fieldName string) string {
+	return strings.ToLower(fieldName)
+}
 
 // A Decoder reads and decodes deb822 values from an input stream.
 type Decoder struct {
-	stanza stanza
-	err    error
-	reader *bufio.Reader
-	atEOF  bool
+	paragraph *Paragraph
+	stanza    stanza
+	err       error
+	reader    *bufio.Reader
+	atEOF     bool
+	strict    bool
+
+	fileName string
+	line     int // number of lines consumed so far
+	stanzas  int // number of stanzas read so far
+
+	// OpenPGP clearsigned input
+	signature *Signature
+	unwrapped bool
+	unwrapErr error
 }
 
 /*
@@ -46,59 +86,118 @@ The decoder introduces its own buffering and may read data from r beyond the JSO
 Note: it drains supplied io.Reader, so do not use it after decoding!
 */
 func NewDecoder(reader io.Reader) *Decoder {
-	return &Decoder{
+	d := &Decoder{
 		reader: bufio.NewReader(reader),
 		atEOF:  false,
 	}
+
+	// a nil *os.File is passed along with the error of a failed os.Open
+	if f, ok := reader.(*os.File); ok && f != nil {
+		d.fileName = f.Name()
+	}
+
+	return d
+}
+
+// SetFileName sets the input file name reported in [ParseError]s. It is detected automatically for [pkg/os.File]s.
+func (d *Decoder) SetFileName(name string) {
+	d.fileName = name
+}
+
+/*
+DisallowDuplicateFields causes the Decoder to return an error when a stanza contains the same field more than once.
+
+Field names are case-insensitive, so `Package` and `package` are duplicates too. Otherwise, the last occurrence wins.
+*/
+func (d *Decoder) DisallowDuplicateFields() {
+	d.strict = true
 }
 
 // reads single stanza from reader
 // returns true if there are more stanzas left
-func (d *Decoder) readStanza() bool {
+//
+// Paragraphs without fields (i.e. comments after the last stanza) are only returned if keepEmpty is set
+func (d *Decoder) readStanza(keepEmpty bool) bool {
 	if d.atEOF {
+		d.err = io.EOF
 		return false
 	}
 
-	d.stanza = make(map[string]string)
-	d.err = nil
+	if d.err = d.unwrap(); d.err != nil {
+		return false
+	}
 
-	var lastKey string
+	d.paragraph, d.err = d.readParagraph()
+	if d.err != nil {
+		return false
+	}
+
+	if !d.paragraph.hasFields() && !keepEmpty {
+		// paragraph without fields may only be the last one
+		d.err = io.EOF
+		return false
+	}
+
+	if d.paragraph.hasFields() {
+		d.stanzas++
+	}
+
+	if d.strict {
+		if first, second, found := d.paragraph.duplicate(); found {
+			d.err = d.errorAt(d.stanzas, second.line, 1, second.name,
+				fmt.Errorf("duplicate field, already defined as '%s' at line %d", first.name, first.line))
+			return false
+		}
+	}
+
+	d.stanza = d.paragraph.stanza()
+	return true
+}
+
+// reads single paragraph from reader, including comments and blank lines preceding and terminating it
+func (d *Decoder) readParagraph() (*Paragraph, error) {
+	p := &Paragraph{}
+	lastField := -1
 
 	for {
 		line, err := d.reader.ReadString('\n')
-		if err == io.EOF && line != "" {
-			err = nil
-			// each paragraph is terminated by a newline, if it is not the case for the last one, let's add it by
-			// ourselves
-			line = line + "\n"
+		if err != nil && err != io.EOF {
+			return nil, err
 		}
-		if err == io.EOF {
-			// if we have a stanza after reaching EOF, it's not an error
-			// subsequent [readStanza] call will return false. We're fine.
-			if len(d.stanza) > 0 {
-				d.atEOF = true
-				return true
+
+		if line == "" {
+			d.atEOF = true
+
+			if len(p.entries) == 0 {
+				return nil, io.EOF
 			}
-			// It's an error otherwise. Propagate it.
-			d.err = err
-			return false
-		} else if err != nil {
-			d.err = err
-			return false
+			// if we have a paragraph after reaching EOF, it's not an error
+			// subsequent [readStanza] call will return false. We're fine.
+			return p, nil
 		}
+		d.line++
+
+		switch {
+		case line == "\n" || line == "\r\n":
+			if lastField == -1 {
+				// blank lines before the first field belong to the paragraph, but do not terminate it
+				p.entries = append(p.entries, paragraphEntry{raw: line, line: d.line})
+				break
+			}
 
-		if line == "\n" || line == "\r\n" {
-			if len(d.stanza) == 0 {
-				// Skip over any number of blank lines between paragraphs.
-				continue
+			// Paragraph is parsed otherwise. Ready for field assignment. Any number of blank lines between
+			// paragraphs belongs to the preceding one.
+			p.trailer = line
+			for d.blankLineAhead() {
+				line, _ = d.reader.ReadString('\n')
+				d.line++
+				p.trailer += line
 			}
-			// Stanza is parsed otherwise. Ready for field assignment.
-			return true
-		}
+			return p, nil
 
-		if strings.HasPrefix(line, "#") {
-			continue // skip comments
-		}
+		case strings.HasPrefix(line, "#"):
+			// comments are not field values, just keep them in place
+			p.entries = append(p.entries, paragraphEntry{raw: line, line: d.line})
 
 		/*
 			So we have a line in one of the following formats:
@@ -108,81 +207,159 @@ func (d *Decoder) readStanza() bool {
 
 			 Foobar is seen as a continuation of the last line, and the Key line is a Key/Value mapping.
 		*/
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"):
+			if lastField == -1 {
+				// continuation without a field to continue, keep it verbatim
+				p.entries = append(p.entries, paragraphEntry{raw: line, line: d.line})
+				break
+			}
 
-		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
-			/*
-				This is a continuation line; so we're going to go ahead and clean it up, and add it into the list. We're
-				going to remove the first character (which we now know is whitespace), and if it's a line that only has
-				a dot on it, we'll remove that too (since " .\n" is actually "\n"). We only trim off space on the right
-				hand, because indentation under the whitespace is up to the data format. Not us.
-			*/
-
-			// TrimFunc(line[1:], unicode.IsSpace) is identical to calling TrimSpace.
-			line = strings.TrimRightFunc(line[1:], unicode.IsSpace)
-
-			if d.stanza[lastKey] == "" {
-				d.stanza[lastKey] = line + "\n"
-			} else {
-				if !strings.HasSuffix(d.stanza[lastKey], "\n") {
-					d.stanza[lastKey] = d.stanza[lastKey] + "\n"
-				}
+			p.entries[lastField].raw += line
+			p.entries[lastField].value = appendContinuation(p.entries[lastField].value, line)
 
-				// no need to add a newline to the multiline temporary storage,
-				// otherwise we'll end up witn an extra paragraph during parsing
-				d.stanza[lastKey] = d.stanza[lastKey] + line
+		default:
+			// Key: Value line parsing
+			key, value, found := strings.Cut(line, ":")
+			if !found {
+				// the stanza being read is not counted yet
+				return nil, d.errorAt(d.stanzas+1, d.line, 1, "",
+					fmt.Errorf("bad line: '%s' has no ':'", strings.TrimRight(line, "\r\n")))
 			}
-			continue
-		}
 
-		// Key: Value line parsing
-		els := strings.SplitN(line, ":", 2)
-		if len(els) != 2 {
-			d.err = fmt.Errorf("bad line: '%s' has no ':'", line)
-			return false
+			/* We'll go ahead and take off any leading spaces */
+			p.entries = append(p.entries, paragraphEntry{
+				name:  strings.TrimSpace(key),
+				value: strings.TrimSpace(value),
+				raw:   line,
+				line:  d.line,
+			})
+			lastField = len(p.entries) - 1
 		}
 
-		/* We'll go ahead and take off any leading spaces */
-		lastKey = strings.TrimSpace(els[0])
-		value := strings.TrimSpace(els[1])
-
-		d.stanza[lastKey] = value
+		if err == io.EOF {
+			// the last line is not terminated by a newline
+			d.atEOF = true
+			return p, nil
+		}
 	}
 }
 
+// true if the next line in the stream is blank
+func (d *Decoder) blankLineAhead() bool {
+	next, _ := d.reader.Peek(2)
+	return len(next) > 0 && (next[0] == '\n' || (next[0] == '\r' && len(next) > 1 && next[1] == '\n'))
+}
+
 // decodes single stanza into res
 // decodes all stanzas till EOF if res is a slice
+//
+// res may also be a *[Paragraph] or a *[]Paragraph to get raw paragraphs
 func (d *Decoder) Decode(res any) error {
 	into := reflect.ValueOf(res)
 
-	if into.Type().Kind() != reflect.Ptr {
-		return fmt.Errorf("Decode can only decode into a pointer")
+	if into.Kind() != reflect.Ptr || into.IsNil() {
+		return fmt.Errorf("Decode requires a non-nil pointer")
 	}
 
 	switch into.Elem().Type().Kind() {
 	case reflect.Struct:
-		d.readStanza()
+		d.readStanza(isParagraph(into.Type()))
 		if d.err != nil {
 			return d.err
 		}
-		return decodeStruct(d.stanza, into)
+		return d.decodeStanza(into)
 	case reflect.Slice:
-		for d.readStanza() {
-			if d.err != nil {
-				return d.err
-			}
-			itemType := into.Elem().Type().Elem()
+		itemType := into.Elem().Type().Elem()
 
+		for d.readStanza(itemType == paragraphType) {
 			item := reflect.New(itemType)
 
-			if err := decodeStruct(d.stanza, item); err == nil {
+			if err := d.decodeStanza(item); err == nil {
 				into.Elem().Set(reflect.Append(into.Elem(), item.Elem()))
 			} else {
 				return err
 			}
 		}
+
+		if d.err != nil && !errors.Is(d.err, io.EOF) {
+			return d.err
+		}
 	default:
 		return fmt.Errorf("unable to decode into a %s", into.Elem().Type().Name())
 	}
 
 	return nil
 }
+
+var paragraphType = reflect.TypeFor[Paragraph]()
+
+// true if t is *Paragraph
+func isParagraph(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem() == paragraphType
+}
+
+// decodes the stanza read last into a struct
+func (d *Decoder) decodeStanza(into reflect.Value) error {
+	if isParagraph(into.Type()) {
+		into.Elem().Set(reflect.ValueOf(*d.paragraph))
+		return nil
+	}
+
+	err := d.paragraph.decodeInto(into)
+
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		pe.File = d.fileName
+		pe.Stanza = d.stanzas
+	}
+
+	return err
+}
+
+// builds [ParseError] for the given stanza
+func (d *Decoder) errorAt(stanza, line, column int, field string, err error) *ParseError {
+	return &ParseError{
+		File:   d.fileName,
+		Line:   line,
+		Column: column,
+		Stanza: stanza,
+		Field:  field,
+		Err:    err,
+	}
+}
+
+/*
+Iter returns an iterator over the stanzas remaining in the decoder's input stream, decoding each one into a fresh T.
+
+Unlike [Decoder.Decode] with a slice, stanzas are read one by one, so the whole stream is never held in memory. Breaking
+out of the loop stops reading. A decoding error is yielded once, as a [*ParseError] with the position it occurred at,
+and ends the iteration.
+
+	for item, err := range deb822.Iter[BinaryIndexItem](decoder) {
+		if err != nil {
+			return err
+		}
+		...
+	}
+
+T must be a struct type or [Paragraph].
+*/
+func Iter[T any](d *Decoder) iter.Seq2[T, error] {
+	keepEmpty := reflect.TypeFor[T]() == paragraphType
+
+	return func(yield func(T, error) bool) {
+		for d.readStanza(keepEmpty) {
+			var item T
+			err := d.decodeStanza(reflect.ValueOf(&item))
+
+			if !yield(item, err) || err != nil {
+				return
+			}
+		}
+
+		if d.err != nil && !errors.Is(d.err, io.EOF) {
+			var zero T
+			yield(zero, d.err)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package deb822_test
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"testing"
 
 	"github.com/aol-nnov/debian/deb822"
 )
@@ -74,3 +77,231 @@ Priority: optional
 	// {first devel optional}
 	// {second devel optional}
 }
+
+func ExampleIter() {
+	const deb822Stream = `Source: first
+Section: devel
+
+Source: second
+Section: libs
+
+Source: third
+Section: games
+`
+	type Result struct {
+		Source, Section string
+	}
+
+	for r, err := range deb822.Iter[Result](deb822.NewDecoder(strings.NewReader(deb822Stream))) {
+		if err != nil {
+			break
+		}
+
+		fmt.Println(r)
+
+		if r.Source == "second" {
+			break
+		}
+	}
+
+	// Output:
+	// {first devel}
+	// {second libs}
+}
+
+func TestIterErrorLine(t *testing.T) {
+	const deb822Stream = `Source: first
+Section: devel
+
+Source: second
+Section libs
+`
+	type Result struct {
+		Source, Section string
+	}
+
+	var decoded int
+	var lastErr error
+	for _, err := range deb822.Iter[Result](deb822.NewDecoder(strings.NewReader(deb822Stream))) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		decoded++
+	}
+
+	if decoded != 1 {
+		t.Errorf("expected 1 stanza decoded before the error, got %d", decoded)
+	}
+
+	var pe *deb822.ParseError
+	if !errors.As(lastErr, &pe) || pe.Line != 5 || pe.Stanza != 2 {
+		t.Errorf("expected error at line 5 of stanza 2, got %v", lastErr)
+	}
+}
+
+func TestDecodeSliceError(t *testing.T) {
+	const deb822Stream = `Source: first
+
+Source second
+`
+	type Result struct {
+		Source string
+	}
+
+	var m []Result
+	if err := deb822.NewDecoder(strings.NewReader(deb822Stream)).Decode(&m); err == nil {
+		t.Fatal("malformed stream must fail to decode")
+	}
+}
+
+func ExampleDecoder_Decode_caseInsensitive() {
+	const deb822Stream = `source: debusine
+SECTION: devel
+Build-depends: debhelper-compat (= 13)
+`
+	type Result struct {
+		Source       string
+		Section      string
+		BuildDepends string `deb822:"Build-Depends"`
+		Binary       string `if_missing:"SOURCE"`
+	}
+	var m Result
+
+	deb822.NewDecoder(strings.NewReader(deb822Stream)).Decode(&m)
+	fmt.Printf("%v", m)
+
+	// Output: {debusine devel debhelper-compat (= 13) debusine}
+}
+
+func TestDisallowDuplicateFields(t *testing.T) {
+	const deb822Stream = `Package: one
+Section: devel
+package: two
+`
+	type Result struct {
+		Package, Section string
+	}
+
+	var lax Result
+	if err := deb822.NewDecoder(strings.NewReader(deb822Stream)).Decode(&lax); err != nil || lax.Package != "two" {
+		t.Fatalf("last duplicate must win in non-strict mode, got %v (%v)", lax, err)
+	}
+
+	var strict Result
+	dec := deb822.NewDecoder(strings.NewReader(deb822Stream))
+	dec.DisallowDuplicateFields()
+
+	var pe *deb822.ParseError
+	if err := dec.Decode(&strict); !errors.As(err, &pe) || pe.Field != "package" || pe.Line != 3 {
+		t.Fatalf("duplicate field must be reported, got %v", err)
+	}
+}
+
+type intField struct {
+	Source string
+	Size   int
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	const deb822Stream = `Source: first
+Size: 1
+
+Source: second
+Size:   many
+`
+	var m []intField
+	err := deb822.NewDecoder(strings.NewReader(deb822Stream)).Decode(&m)
+
+	var pe *deb822.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+
+	if pe.Line != 5 || pe.Column != 9 || pe.Stanza != 2 || pe.Field != "Size" {
+		t.Fatalf("wrong position %+v", pe)
+	}
+}
+
+func ExampleParseError() {
+	in, _ := os.CreateTemp("", "control")
+	defer os.Remove(in.Name())
+
+	in.WriteString("Source: hello\nSize: big\n")
+	in.Seek(0, 0)
+
+	var m intField
+	err := deb822.NewDecoder(in).Decode(&m)
+
+	var pe *deb822.ParseError
+	if errors.As(err, &pe) {
+		fmt.Printf("line %d, column %d, field %s: %v", pe.Line, pe.Column, pe.Field, pe.Err)
+	}
+
+	// Output: line 2, column 7, field Size: strconv.Atoi: parsing "big": invalid syntax
+}
+
+func ExampleDecoder_Decode_extra() {
+	var res struct {
+		Package string
+		Extra   map[string]string `deb822:",extra"`
+	}
+
+	deb822.NewDecoder(strings.NewReader("Package: hello\nBuilt-Using: gcc-12 (= 12.2.0-14)\n")).Decode(&res)
+
+	fmt.Println(res.Package, res.Extra)
+
+	// Output: hello map[Built-Using:gcc-12 (= 12.2.0-14)]
+}
+
+// enum based on int
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	*l = level(len(text))
+	return nil
+}
+
+type embedded struct {
+	Name string `deb822:"Package"`
+}
+
+func TestDecodeTextUnmarshaler(t *testing.T) {
+	var res struct {
+		embedded
+		Level   level
+		Numbers []string `delim:"," strip:" "`
+	}
+
+	if err := deb822.NewDecoder(strings.NewReader("Package: hello\nLevel: high\nNumbers: one, two,\n")).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Name != "hello" || res.Level != 4 || len(res.Numbers) != 2 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestNewDecoderNilFile(t *testing.T) {
+	// os.Open failed, the error is handled later on
+	f, _ := os.Open("testdata/missing")
+
+	var res struct{ Package string }
+	if err := deb822.NewDecoder(f).Decode(&res); err == nil {
+		t.Fatal("expected an error decoding from a nil file")
+	}
+}
+
+type requiredPackage struct {
+	Package string `required:"true"`
+	Version string
+}
+
+func TestParseErrorRequiredField(t *testing.T) {
+	var res requiredPackage
+
+	err := deb822.Unmarshal("Version: 1.0\n", &res)
+	if err == nil || err.Error() != "<input>:1: stanza 1: field 'Package': requiredPackage: required field is missing" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package deb822
 
 import (
+	"encoding"
+	"fmt"
 	"io"
 	"reflect"
 )
@@ -8,20 +10,34 @@ import (
 func encodeSlice(w io.Writer, items reflect.Value, fieldType reflect.StructField, delimiter string) (err error) {
 	if it := fieldType.Tag.Get("delim"); it != "" {
 		delimiter = it
-		if delimiter != "\n" {
+		if delimiter != "\n" && delimiter != " " {
 			delimiter = it + " "
 		}
 	}
 
+	// newline delimited field values (i.e. Files) have an item per continuation line
+	lines := fieldType.Name != "" && delimiter == "\n"
+
 	for idx := 0; idx < items.Len(); idx++ {
-		if idx > 0 {
+		if lines {
+			w.Write([]byte("\n "))
+		} else if idx > 0 {
 			w.Write([]byte(delimiter))
 		}
 
-		item := items.Index(idx)
-		switch item.Kind() {
-		case reflect.Struct:
-			err = encodeStruct(w, reflect.Indirect(item))
+		item := reflect.Indirect(items.Index(idx))
+		switch {
+		case item.CanAddr() && item.Addr().Type().Implements(reflect.TypeFor[encoding.TextMarshaler]()):
+			var text []byte
+			if text, err = item.Addr().Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+				_, err = w.Write(text)
+			}
+		case item.Kind() == reflect.Struct:
+			err = encodeStruct(w, item)
+		case item.Kind() == reflect.String:
+			_, err = io.WriteString(w, item.String())
+		default:
+			err = fmt.Errorf("unable to encode from a %s", item.Type().String())
 		}
 
 		if err != nil {
@@ -1,10 +1,13 @@
 package deb822
 
 import (
+	"bytes"
 	"encoding"
 	"fmt"
 	"io"
+	"maps"
 	"reflect"
+	"slices"
 )
 
 func isEmptyValue(v reflect.Value) bool {
@@ -18,7 +21,7 @@ func isEmptyValue(v reflect.Value) bool {
 		reflect.Interface, reflect.Pointer:
 		return v.IsZero()
 	case reflect.Struct:
-		return reflect.Zero(v.Type()) == v
+		return v.IsZero()
 	}
 	return false
 }
@@ -29,6 +32,13 @@ func encodeStruct(w io.Writer, from reflect.Value) error {
 		return encodeStruct(w, from.Elem())
 	}
 
+	// values passed to the encoder directly are not addressable, pointer receivers need a copy
+	if !from.CanAddr() {
+		addressable := reflect.New(from.Type()).Elem()
+		addressable.Set(from)
+		from = addressable
+	}
+
 	if marshal, ok := from.Addr().Interface().(encoding.TextMarshaler); ok {
 		// fmt.Fprintf(os.Stderr, "encodeStruct %T is TextMarshaler\n", from.Addr().Interface())
 		var err error
@@ -42,7 +52,13 @@ func encodeStruct(w io.Writer, from reflect.Value) error {
 	// 	fmt.Fprintf(os.Stderr, "encodeStruct %T is NOT TextMarshaler\n", from.Addr().Interface())
 	// }
 
-	for idx, field := range reflect.VisibleFields(from.Type()) {
+	for _, field := range reflect.VisibleFields(from.Type()) {
+		// fields of embedded structs are visited on their own
+		if !field.IsExported() || field.Anonymous && field.Type.Kind() == reflect.Struct {
+			continue
+		}
+
+		value := from.FieldByIndex(field.Index)
 		fieldName := field.Name
 
 		nameFromTag, opts := parseTag(field.Tag.Get("deb822"))
@@ -55,7 +71,15 @@ func encodeStruct(w io.Writer, from reflect.Value) error {
 			continue
 		}
 
-		if isEmptyValue(from.Field(idx)) {
+		if opts.Contain("extra") {
+			extra := value
+			for _, key := range slices.Sorted(maps.Keys(extra.Interface().(map[string]string))) {
+				fmt.Fprint(w, formatField(key, extra.MapIndex(reflect.ValueOf(key)).String(), false, "\n"))
+			}
+			continue
+		}
+
+		if isEmptyValue(value) {
 			if opts.Contain("omitempty") {
 				continue
 			}
@@ -65,13 +89,18 @@ func encodeStruct(w io.Writer, from reflect.Value) error {
 			}
 		}
 
-		if fieldName != "" {
-			fmt.Fprintf(w, "%s: ", fieldName)
+		var encoded bytes.Buffer
+		if err := encodeStructValue(&encoded, value, field); err != nil {
+			return fmt.Errorf("encodeStruct: field '%s': %w", fieldName, err)
 		}
 
-		encodeStructValue(w, from.Field(idx), field)
+		// multiline lists (i.e. Files) start on a continuation line
+		separator := " "
+		if encoded.Len() == 0 || encoded.Bytes()[0] == '\n' {
+			separator = ""
+		}
 
-		fmt.Fprint(w, "\n")
+		fmt.Fprintf(w, "%s:%s%s\n", fieldName, separator, encoded.Bytes())
 	}
 
 	return nil
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 func encodeStructValue(w io.Writer, field reflect.Value, fieldType reflect.StructField) (err error) {
@@ -29,7 +31,17 @@ func encodeStructValue(w io.Writer, field reflect.Value, fieldType reflect.Struc
 
 	switch field.Kind() {
 	case reflect.String:
-		_, err = fmt.Fprint(w, field.String())
+		_, err = io.WriteString(w, formatValue(field.String()))
+	case reflect.Bool:
+		if field.Bool() {
+			_, err = io.WriteString(w, "yes")
+		} else {
+			_, err = io.WriteString(w, "no")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = io.WriteString(w, strconv.FormatInt(field.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = io.WriteString(w, strconv.FormatUint(field.Uint(), 10))
 	case reflect.Struct:
 		err = encodeStruct(w, field)
 	case reflect.Slice:
@@ -39,3 +51,15 @@ func encodeStructValue(w io.Writer, field reflect.Value, fieldType reflect.Struc
 	}
 	return
 }
+
+// multiline values are written as continuation lines, empty lines are written as " ."
+func formatValue(value string) string {
+	lines := strings.Split(strings.TrimSuffix(value, "\n"), "\n")
+	for idx, line := range lines[1:] {
+		if line == "" {
+			line = "."
+		}
+		lines[idx+1] = " " + line
+	}
+	return strings.Join(lines, "\n")
+}
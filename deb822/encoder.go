@@ -17,6 +17,18 @@ func NewEncoder(w io.Writer) *Encoder {
 }
 
 func (enc *Encoder) Encode(v any) (err error) {
+	// raw paragraphs are written as is
+	switch p := v.(type) {
+	case Paragraph:
+		_, err = p.WriteTo(enc.writer)
+		return
+	case *Paragraph:
+		_, err = p.WriteTo(enc.writer)
+		return
+	case []Paragraph:
+		return encodeParagraphs(enc.writer, p)
+	}
+
 	from := reflect.ValueOf(v)
 
 	switch from.Kind() {
@@ -30,3 +42,19 @@ func (enc *Encoder) Encode(v any) (err error) {
 
 	return
 }
+
+func encodeParagraphs(w io.Writer, paragraphs []Paragraph) error {
+	for idx := range paragraphs {
+		if _, err := paragraphs[idx].WriteTo(w); err != nil {
+			return err
+		}
+
+		// paragraphs built from scratch have no separator
+		if idx < len(paragraphs)-1 && paragraphs[idx].trailer == "" && paragraphs[idx].hasFields() {
+			if _, err := io.WriteString(w, paragraphs[idx].eol()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -16,7 +16,7 @@ func ExampleEncoder_string_noFieldName() {
 	// unable to encode from a string
 }
 
-func ExampleEncoder_Description() {
+func ExampleEncoder_description() {
 	dStr := `First line
 second line
 
@@ -63,7 +63,6 @@ After empty line`,
 			StandardsVersion: fields.Version{
 				UpstreamVersion: "1.2.3",
 			},
-			// Description:       "descr",
 			BuildDepends:      []fields.Dependency{},
 			BuildDependsArch:  []fields.Dependency{},
 			BuildDependsIndep: []fields.Dependency{},
@@ -89,5 +88,54 @@ After empty line`,
 	// Section: libs
 	// Priority: optional
 	// Standards-Version: 1.2.3
+	// <nil>
+}
+
+func ExampleEncoder_requiredField() {
+	in := struct {
+		Name        string `deb822:"Package"`
+		Description string `required:"true"`
+	}{
+		Name: "hello",
+	}
+
+	_, err := deb822.Marshal(in)
+	fmt.Println(err)
+
+	// Output:
 	// encodeStruct: missing value for required field 'Description'
 }
+
+type encodedBase struct {
+	Name string `deb822:"Package"`
+}
+
+func ExampleEncoder_Encode_kinds() {
+	in := struct {
+		encodedBase
+		Essential     bool
+		InstalledSize int            `deb822:"Installed-Size"`
+		Tag           []string       `delim:","`
+		Version       fields.Version `deb822:",omitempty"`
+		Description   string
+	}{
+		encodedBase:   encodedBase{"hello"},
+		Essential:     true,
+		InstalledSize: 42,
+		Tag:           []string{"devel::lang:c", "role::program"},
+		Description:   "short\nlong\n\nsecond paragraph",
+	}
+
+	out, _ := deb822.Marshal(in)
+	fmt.Print(out)
+
+	// Output:
+	// Package: hello
+	// Essential: yes
+	// Installed-Size: 42
+	// Tag: devel::lang:c, role::program
+	// Description: short
+	//  long
+	//  .
+	//  second paragraph
+}
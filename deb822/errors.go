@@ -0,0 +1,72 @@
+package deb822
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ParseError is returned by [Decoder] and [Paragraph.Decode] for malformed input and for values, which can not be decoded
+into the target struct. It carries the position of the problem, so it can be reported in a compiler-like manner:
+
+	var pe *deb822.ParseError
+	if errors.As(err, &pe) {
+		fmt.Printf("%s:%d:%d: %v\n", pe.File, pe.Line, pe.Column, pe.Err)
+	}
+*/
+type ParseError struct {
+	File   string // input file name, empty if unknown
+	Line   int    // 1-based line number, 0 if unknown
+	Column int    // 1-based column number, 0 if unknown
+	Stanza int    // 1-based index of the stanza in the input stream, 0 if unknown
+	Field  string // offending field name, empty if the error is not related to a particular field
+	Err    error  // the actual error
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+
+	if e.File != "" {
+		sb.WriteString(e.File)
+	} else {
+		sb.WriteString("<input>")
+	}
+	// unknown position is omitted rather than reported as zero
+	if e.Line > 0 {
+		fmt.Fprintf(&sb, ":%d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&sb, ":%d", e.Column)
+		}
+	}
+	sb.WriteString(": ")
+
+	if e.Stanza > 0 {
+		fmt.Fprintf(&sb, "stanza %d: ", e.Stanza)
+	}
+
+	if e.Field != "" {
+		fmt.Fprintf(&sb, "field '%s': ", e.Field)
+	}
+
+	sb.WriteString(e.Err.Error())
+
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// error related to a particular field, returned by [decodeStruct]. Gets converted to [ParseError] by [Paragraph]
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e *fieldError) Error() string {
+	return fmt.Sprintf("field '%s': %v", e.field, e.err)
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
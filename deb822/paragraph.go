@@ -0,0 +1,291 @@
+package deb822
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// single line (or a group of lines for multiline fields) of a [Paragraph]
+type paragraphEntry struct {
+	name  string // field name as it appears in the source, empty for comments and other non-field lines
+	value string // decoded field value
+	raw   string // verbatim source text, line endings included
+	line  int    // line number the entry starts at
+}
+
+/*
+Paragraph is a raw deb822 paragraph (stanza), which keeps everything needed to reproduce its source text byte by byte:
+field order, original field name case, comment lines, continuation lines formatting and blank lines separating it from
+the next paragraph.
+
+Fields, which were not changed with [Paragraph.Set], are written back exactly as they were read. Use it to edit control
+files in place:
+
+	var paragraphs []deb822.Paragraph
+	deb822.NewDecoder(in).Decode(&paragraphs)
+
+	paragraphs[0].Set("Standards-Version", "4.7.0")
+
+	deb822.NewEncoder(out).Encode(paragraphs)
+
+Field names are case-insensitive, as per deb822(5).
+
+The zero value is an empty paragraph ready to use.
+*/
+type Paragraph struct {
+	entries []paragraphEntry
+	trailer string // blank lines terminating the paragraph
+}
+
+// 1-based column the value starts at
+func (e paragraphEntry) valueColumn() int {
+	colon := strings.IndexByte(e.raw, ':')
+	if colon == -1 {
+		return 0
+	}
+
+	value := e.raw[colon+1:]
+	return colon + 2 + len(value) - len(strings.TrimLeft(value, " \t"))
+}
+
+// returns index of the first entry for the field name, or -1
+func (p *Paragraph) index(name string) int {
+	for idx, e := range p.entries {
+		if e.name != "" && strings.EqualFold(e.name, name) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// true if paragraph has at least one field
+func (p *Paragraph) hasFields() bool {
+	return slices.ContainsFunc(p.entries, func(e paragraphEntry) bool { return e.name != "" })
+}
+
+// Get returns the value of the field name and whether it is present
+func (p *Paragraph) Get(name string) (string, bool) {
+	if idx := p.index(name); idx != -1 {
+		return p.entries[idx].value, true
+	}
+	return "", false
+}
+
+// Keys returns field names in the order of appearance, as they were spelled in the source
+func (p *Paragraph) Keys() []string {
+	var res []string
+	for _, e := range p.entries {
+		if e.name != "" {
+			res = append(res, e.name)
+		}
+	}
+	return res
+}
+
+// All returns an iterator over field names and values in the order of appearance
+func (p *Paragraph) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for _, e := range p.entries {
+			if e.name != "" && !yield(e.name, e.value) {
+				return
+			}
+		}
+	}
+}
+
+/*
+Set assigns value to the field name.
+
+Existing field keeps its position and name spelling, only its text is regenerated. If the value is the same, the field
+is left untouched. Missing field is appended to the end of the paragraph.
+
+Multiline values are written as continuation lines, empty lines are written as " .". If the field used to start its
+value on a continuation line (like `Build-Depends:` in debian/control usually does), the style is preserved.
+*/
+func (p *Paragraph) Set(name, value string) {
+	if idx := p.index(name); idx != -1 {
+		e := &p.entries[idx]
+		if e.value == value {
+			return
+		}
+
+		firstLine, _, _ := strings.Cut(e.raw, "\n")
+		_, firstValue, _ := strings.Cut(firstLine, ":")
+		continuationFirst := strings.TrimSpace(firstValue) == "" && strings.Contains(value, "\n")
+
+		e.value = value
+		e.raw = formatField(e.name, value, continuationFirst, p.eol())
+		return
+	}
+
+	// previous last line may lack a newline, if it was the last line in the stream
+	if last := len(p.entries) - 1; last >= 0 && !strings.HasSuffix(p.entries[last].raw, "\n") {
+		p.entries[last].raw += p.eol()
+	}
+
+	p.entries = append(p.entries, paragraphEntry{
+		name:  name,
+		value: value,
+		raw:   formatField(name, value, false, p.eol()),
+	})
+}
+
+// Delete removes all occurrences of the field name. Returns true if anything was removed
+func (p *Paragraph) Delete(name string) bool {
+	found := false
+	for idx := p.index(name); idx != -1; idx = p.index(name) {
+		p.entries = append(p.entries[:idx], p.entries[idx+1:]...)
+		found = true
+	}
+	return found
+}
+
+// Decode decodes paragraph fields into a struct pointed to by v. See [Decoder] for supported struct tags.
+//
+// Errors are reported as [*ParseError].
+func (p *Paragraph) Decode(v any) error {
+	into := reflect.ValueOf(v)
+	if into.Kind() != reflect.Ptr || into.IsNil() {
+		return errors.New("Decode requires a non-nil pointer")
+	}
+	if into.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unable to decode into a %s", into.Elem().Type())
+	}
+
+	return p.decodeInto(into)
+}
+
+func (p *Paragraph) decodeInto(into reflect.Value) error {
+	err := decodeStruct(p.stanza(), into)
+	if err == nil {
+		return nil
+	}
+
+	res := &ParseError{Err: err}
+	if idx := slices.IndexFunc(p.entries, func(e paragraphEntry) bool { return e.name != "" }); idx != -1 {
+		res.Line = p.entries[idx].line
+	}
+
+	var fe *fieldError
+	if errors.As(err, &fe) {
+		res.Field = fe.field
+		res.Err = fe.err
+
+		if idx := p.index(fe.field); idx != -1 {
+			res.Field = p.entries[idx].name
+			res.Line = p.entries[idx].line
+			res.Column = p.entries[idx].valueColumn()
+		}
+	}
+
+	return res
+}
+
+// WriteTo writes paragraph source text to w. [pkg/io.WriterTo] interface implementation
+func (p *Paragraph) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, p.String())
+	return int64(n), err
+}
+
+// [pkg/fmt.Stringer] interface implementation
+func (p Paragraph) String() string {
+	var sb strings.Builder
+	for _, e := range p.entries {
+		sb.WriteString(e.raw)
+	}
+	sb.WriteString(p.trailer)
+	return sb.String()
+}
+
+// line ending used in the paragraph
+func (p *Paragraph) eol() string {
+	for _, e := range p.entries {
+		if strings.HasSuffix(e.raw, "\r\n") {
+			return "\r\n"
+		}
+	}
+	return "\n"
+}
+
+// builds field lookup table for struct decoding
+func (p *Paragraph) stanza() stanza {
+	s := make(stanza, len(p.entries))
+	for _, e := range p.entries {
+		if e.name != "" {
+			s[stanzaKey(e.name)] = stanzaField{e.name, e.value}
+		}
+	}
+	return s
+}
+
+// returns the first field, which repeats (case-insensitively) an earlier one, along with that earlier field
+func (p *Paragraph) duplicate() (first, second *paragraphEntry, found bool) {
+	seen := make(map[string]int, len(p.entries))
+	for idx, e := range p.entries {
+		if e.name == "" {
+			continue
+		}
+
+		if firstIdx, found := seen[stanzaKey(e.name)]; found {
+			return &p.entries[firstIdx], &p.entries[idx], true
+		}
+		seen[stanzaKey(e.name)] = idx
+	}
+	return nil, nil, false
+}
+
+func formatField(name, value string, continuationFirst bool, eol string) string {
+	lines := strings.Split(strings.TrimSuffix(value, "\n"), "\n")
+	if continuationFirst && lines[0] != "" {
+		lines = append([]string{""}, lines...)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteString(":")
+	for idx, line := range lines {
+		switch {
+		case idx == 0 && line != "":
+			sb.WriteString(" " + line)
+		case idx == 0:
+		case line == "":
+			sb.WriteString(eol + " .")
+		default:
+			sb.WriteString(eol + " " + line)
+		}
+	}
+	sb.WriteString(eol)
+
+	return sb.String()
+}
+
+// appends continuation line to the field value
+func appendContinuation(value, line string) string {
+	/*
+		This is a continuation line; so we're going to go ahead and clean it up, and add it into the list. We're
+		going to remove the first character (which we now know is whitespace), and if it's a line that only has
+		a dot on it, we'll remove that too (since " .\n" is actually "\n"). We only trim off space on the right
+		hand, because indentation under the whitespace is up to the data format. Not us.
+	*/
+
+	// TrimFunc(line[1:], unicode.IsSpace) is identical to calling TrimSpace.
+	line = strings.TrimRightFunc(line[1:], unicode.IsSpace)
+
+	if value == "" {
+		return line + "\n"
+	}
+
+	if !strings.HasSuffix(value, "\n") {
+		value = value + "\n"
+	}
+
+	// no need to add a newline to the multiline temporary storage,
+	// otherwise we'll end up witn an extra paragraph during parsing
+	return value + line
+}
@@ -0,0 +1,161 @@
+package deb822_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/deb822"
+)
+
+const controlWithComments = `# leading comment
+
+Source: debusine
+section: devel
+# comment inside a paragraph
+Build-Depends:
+ debhelper-compat (= 13),
+ dh-python,
+Standards-Version: 4.6.1
+
+
+Package: python3-debusine
+Architecture: all
+Description: Main Python library for debusine
+ Debusine is a general purpose software factory.
+ .
+ This package contains most of the Python libraries.
+# trailing comment`
+
+func TestParagraphRoundTrip(t *testing.T) {
+	for _, in := range []string{
+		controlWithComments,
+		controlWithComments + "\n\n\n",
+		strings.ReplaceAll(controlWithComments, "\n", "\r\n"),
+		"\n\nSource: one\n",
+	} {
+		var paragraphs []deb822.Paragraph
+		if err := deb822.NewDecoder(strings.NewReader(in)).Decode(&paragraphs); err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		if err := deb822.NewEncoder(&out).Encode(paragraphs); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != in {
+			t.Fatalf("round trip failed:\n%q\n%q", in, out.String())
+		}
+	}
+}
+
+func TestParagraphRoundTripControl(t *testing.T) {
+	in, err := os.ReadFile("../pkg/testdata/control")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	for p, err := range deb822.Iter[deb822.Paragraph](deb822.NewDecoder(bytes.NewReader(in))) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.WriteTo(&out)
+	}
+
+	if !bytes.Equal(in, out.Bytes()) {
+		t.Fatal("debian/control round trip is not byte-identical")
+	}
+}
+
+func TestParagraphSet(t *testing.T) {
+	var p deb822.Paragraph
+	if err := deb822.NewDecoder(strings.NewReader(controlWithComments)).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := p.Get("Section"); v != "devel" {
+		t.Fatalf("case-insensitive Get failed, got '%s'", v)
+	}
+
+	p.Set("SECTION", "libs")
+	p.Set("Build-Depends", "debhelper-compat (= 13),\ndh-python,\npython3,")
+	p.Set("Rules-Requires-Root", "no")
+	p.Delete("Standards-Version")
+
+	expected := `# leading comment
+
+Source: debusine
+section: libs
+# comment inside a paragraph
+Build-Depends:
+ debhelper-compat (= 13),
+ dh-python,
+ python3,
+Rules-Requires-Root: no
+
+
+`
+	if p.String() != expected {
+		t.Fatalf("unexpected result:\n%s", p.String())
+	}
+
+	if keys := p.Keys(); strings.Join(keys, " ") != "Source section Build-Depends Rules-Requires-Root" {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
+
+func ExampleParagraph() {
+	var p deb822.Paragraph
+	p.Set("Package", "hello")
+	p.Set("Description", "short\nlong description\n\nsecond paragraph")
+
+	fmt.Print(p.String())
+
+	// Output:
+	// Package: hello
+	// Description: short
+	//  long description
+	//  .
+	//  second paragraph
+}
+
+func ExampleParagraph_Decode() {
+	var p deb822.Paragraph
+	deb822.NewDecoder(strings.NewReader("Source: hello\nSection: devel\n")).Decode(&p)
+
+	var res struct {
+		Source, Section string
+	}
+	p.Decode(&res)
+
+	fmt.Println(res)
+
+	// Output: {hello devel}
+}
+
+func TestParagraphDecodeNonPointer(t *testing.T) {
+	var p deb822.Paragraph
+	if err := deb822.Unmarshal("Source: hello\n", &p); err != nil {
+		t.Fatal(err)
+	}
+
+	type source struct {
+		Source string
+	}
+	var nilSource *source
+	var name string
+
+	for _, v := range []any{nil, source{}, nilSource, &name} {
+		if err := p.Decode(v); err == nil {
+			t.Fatalf("decoding into %#v must fail", v)
+		}
+	}
+
+	if err := deb822.Unmarshal("Source: hello\n", nilSource); err == nil {
+		t.Fatal("decoding into nil pointer must fail")
+	}
+}
@@ -7,8 +7,9 @@ import (
 
 func Marshal(v any) (string, error) {
 	res := new(bytes.Buffer)
+	err := NewEncoder(res).Encode(v)
 
-	return res.String(), NewEncoder(res).Encode(v)
+	return res.String(), err
 }
 
 func Unmarshal(data string, v any) error {
@@ -0,0 +1,94 @@
+package deb822
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+)
+
+var (
+	clearsignStart = []byte("-----BEGIN PGP SIGNED MESSAGE-----")
+	signatureStart = []byte("-----BEGIN PGP SIGNATURE-----")
+	signatureEnd   = []byte("-----END PGP SIGNATURE-----")
+)
+
+/*
+Signature of an OpenPGP clearsigned input (i.e. InRelease, signed .dsc or .changes), see [Decoder.Signature].
+
+It is not verified by the decoder, call [Signature.Verify] with a keyring of trusted keys.
+*/
+type Signature struct {
+	Signed  []byte   // signed text, as it is hashed by OpenPGP
+	Armored []byte   // armored signature block
+	Hash    []string // hash algorithms from the message armor headers, not verified
+}
+
+// Verify checks the signature against keyring and returns the signer. It may be called any number of times.
+func (s *Signature) Verify(keyring openpgp.KeyRing) (*openpgp.Entity, error) {
+	return openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(s.Signed), bytes.NewReader(s.Armored), nil)
+}
+
+/*
+Signature returns the signature of an OpenPGP clearsigned input, or nil if the input is not signed.
+
+Signed input is detected and unwrapped transparently, so the decoder sees only the signed text. This may be called
+before decoding anything.
+*/
+func (d *Decoder) Signature() *Signature {
+	d.unwrap()
+	return d.signature
+}
+
+// detects OpenPGP clearsigned input and replaces the reader with the signed text. Does the job once
+func (d *Decoder) unwrap() error {
+	if d.unwrapped {
+		return d.unwrapErr
+	}
+	d.unwrapped = true
+
+	if head, _ := d.reader.Peek(len(clearsignStart)); !bytes.Equal(head, clearsignStart) {
+		return nil
+	}
+
+	// signed documents are small, it's fine to have the whole document in memory
+	data, err := io.ReadAll(d.reader)
+	if err != nil {
+		d.unwrapErr = err
+		return err
+	}
+
+	block, _ := clearsign.Decode(data)
+	if block == nil {
+		d.unwrapErr = d.errorAt(1, 1, 1, "", fmt.Errorf("malformed OpenPGP signed message"))
+		return d.unwrapErr
+	}
+
+	d.signature = &Signature{
+		Signed: block.Bytes,
+		Hash:   block.Headers.Values("Hash"),
+	}
+
+	if start := bytes.Index(data, signatureStart); start != -1 {
+		if end := bytes.Index(data[start:], signatureEnd); end != -1 {
+			d.signature.Armored = data[start : start+end+len(signatureEnd)]
+		}
+	}
+
+	// armor header lines (the BEGIN line, Hash headers and a blank line) precede the signed text, so let's keep
+	// line numbers pointing to the source
+	for line, rest := []byte(nil), data; len(rest) > 0; {
+		line, rest, _ = bytes.Cut(rest, []byte{'\n'})
+		d.line++
+		if len(bytes.TrimSpace(line)) == 0 {
+			break
+		}
+	}
+
+	d.reader = bufio.NewReader(bytes.NewReader(block.Plaintext))
+
+	return nil
+}
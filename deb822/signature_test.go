@@ -0,0 +1,89 @@
+package deb822_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/aol-nnov/debian/deb822"
+)
+
+func clearsigned(t *testing.T, text string) (openpgp.EntityList, []byte) {
+	entity, err := openpgp.NewEntity("Test", "", "test@example.net", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w, err := clearsign.Encode(&buf, entity.PrivateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(text))
+	w.Close()
+
+	return openpgp.EntityList{entity}, buf.Bytes()
+}
+
+func TestDecodeSigned(t *testing.T) {
+	keyring, signed := clearsigned(t, "Origin: Debian\nSuite: unstable\n")
+
+	var release struct {
+		Origin, Suite string
+	}
+
+	d := deb822.NewDecoder(bytes.NewReader(signed))
+	if err := d.Decode(&release); err != nil {
+		t.Fatal(err)
+	}
+
+	if release.Origin != "Debian" || release.Suite != "unstable" {
+		t.Fatalf("unexpected result %+v", release)
+	}
+
+	sig := d.Signature()
+	if sig == nil {
+		t.Fatal("signature not found")
+	}
+
+	for range 2 {
+		if _, err := sig.Verify(keyring); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	otherKeyring, _ := clearsigned(t, "")
+	if _, err := sig.Verify(otherKeyring); err == nil {
+		t.Fatal("signature verified with a wrong key")
+	}
+}
+
+func TestDecodeSignedTampered(t *testing.T) {
+	keyring, signed := clearsigned(t, "Origin: Debian\nSuite: unstable\n")
+
+	d := deb822.NewDecoder(bytes.NewReader(bytes.Replace(signed, []byte("unstable"), []byte("stable"), 1)))
+	if _, err := d.Signature().Verify(keyring); err == nil {
+		t.Fatal("tampered message verified")
+	}
+}
+
+func TestDecodeSignedErrorLine(t *testing.T) {
+	_, signed := clearsigned(t, "Origin: Debian\nbroken line\n")
+
+	var release struct{ Origin string }
+	err := deb822.NewDecoder(bytes.NewReader(signed)).Decode(&release)
+
+	var pe *deb822.ParseError
+	if !errors.As(err, &pe) || pe.Line != 5 {
+		t.Fatalf("expected error at line 5, got %v", err)
+	}
+}
+
+func TestDecodeUnsigned(t *testing.T) {
+	if deb822.NewDecoder(strings.NewReader("Origin: Debian\n")).Signature() != nil {
+		t.Fatal("unsigned input reported as signed")
+	}
+}
@@ -0,0 +1,341 @@
+/*
+Package dsc reads, generates and verifies Debian source control files (.dsc).
+
+https://manpages.debian.org/dsc.5
+*/
+package dsc
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aol-nnov/debian/deb822"
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/pkg"
+)
+
+// Dsc is a source package control file
+type Dsc struct {
+	Format            string
+	Source            string                `required:"true"`
+	Binary            []string              `deb822:",omitempty" delim:"," strip:" \n"`
+	Architecture      []fields.Architecture `deb822:",omitempty" delim:" " strip:" "`
+	Version           fields.Version        `required:"true"`
+	Maintainer        string
+	Uploaders         string         `deb822:",omitempty"`
+	Homepage          string         `deb822:",omitempty"`
+	StandardsVersion  fields.Version `deb822:"Standards-Version,omitempty"`
+	VcsBrowser        string         `deb822:"Vcs-Browser,omitempty"`
+	VcsGit            string         `deb822:"Vcs-Git,omitempty"`
+	Testsuite         []string       `deb822:",omitempty" delim:"," strip:" "`
+	TestsuiteTriggers []string       `deb822:"Testsuite-Triggers,omitempty" delim:"," strip:" "`
+
+	BuildDepends        fields.Dependencies `deb822:"Build-Depends,omitempty" delim:"," strip:" \n"`
+	BuildDependsArch    fields.Dependencies `deb822:"Build-Depends-Arch,omitempty" delim:"," strip:" \n"`
+	BuildDependsIndep   fields.Dependencies `deb822:"Build-Depends-Indep,omitempty" delim:"," strip:" \n"`
+	BuildConflicts      fields.Dependencies `deb822:"Build-Conflicts,omitempty" delim:"," strip:" \n"`
+	BuildConflictsArch  fields.Dependencies `deb822:"Build-Conflicts-Arch,omitempty" delim:"," strip:" \n"`
+	BuildConflictsIndep fields.Dependencies `deb822:"Build-Conflicts-Indep,omitempty" delim:"," strip:" \n"`
+
+	// fields not listed above, i.e. Vcs-Svn or Dgit
+	Extra map[string]string `deb822:",extra"`
+
+	PackageList     []PackageListEntry `deb822:"Package-List,omitempty" delim:"\n" strip:" \n"`
+	ChecksumsSha1   []File             `deb822:"Checksums-Sha1,omitempty" delim:"\n" strip:" \n"`
+	ChecksumsSha256 []File             `deb822:"Checksums-Sha256,omitempty" delim:"\n" strip:" \n"`
+	Files           []File             `delim:"\n" strip:" \n" required:"true"` // MD5 checksums
+
+	// signature of a clearsigned .dsc, nil if it is not signed
+	Signature *deb822.Signature `deb822:"-"`
+}
+
+// File is a source artifact, referenced by .dsc
+type File struct {
+	Checksum string
+	Size     int64
+	Name     string
+}
+
+// [pkg/encoding.TextUnmarshaler] interface implementation
+func (f *File) UnmarshalText(text []byte) (err error) {
+	tmp := bytes.Fields(text)
+	if len(tmp) != 3 {
+		return fmt.Errorf("unable to unmarshal File record '%s'", text)
+	}
+
+	f.Checksum = string(tmp[0])
+	if f.Size, err = strconv.ParseInt(string(tmp[1]), 10, 64); err != nil {
+		return fmt.Errorf("unable to unmarshal File record '%s': %w", text, err)
+	}
+	f.Name = string(tmp[2])
+
+	return nil
+}
+
+// [pkg/encoding.TextMarshaler] interface implementation
+func (f File) MarshalText() (text []byte, err error) {
+	return fmt.Appendf(nil, "%s %d %s", f.Checksum, f.Size, f.Name), nil
+}
+
+// PackageListEntry describes a binary package built from the source, i.e. "hello deb devel optional arch=any"
+type PackageListEntry struct {
+	Name     string
+	Type     string // deb or udeb
+	Section  string
+	Priority string
+	Options  []string // key=value pairs, i.e. arch=amd64,arm64 or profile=!stage1
+}
+
+// [pkg/encoding.TextUnmarshaler] interface implementation
+func (e *PackageListEntry) UnmarshalText(text []byte) error {
+	tmp := strings.Fields(string(text))
+	if len(tmp) < 4 {
+		return fmt.Errorf("unable to unmarshal Package-List record '%s'", text)
+	}
+
+	e.Name, e.Type, e.Section, e.Priority = tmp[0], tmp[1], tmp[2], tmp[3]
+	e.Options = tmp[4:]
+
+	return nil
+}
+
+// [pkg/encoding.TextMarshaler] interface implementation
+func (e PackageListEntry) MarshalText() (text []byte, err error) {
+	return []byte(strings.Join(append([]string{e.Name, e.Type, e.Section, e.Priority}, e.Options...), " ")), nil
+}
+
+// FromStream decodes .dsc file. Clearsigned input is accepted, use Dsc.Signature to verify it
+func FromStream(r io.Reader) (*Dsc, error) {
+	var d Dsc
+
+	decoder := deb822.NewDecoder(r)
+	if err := decoder.Decode(&d); err != nil {
+		return nil, err
+	}
+	d.Signature = decoder.Signature()
+
+	return &d, nil
+}
+
+// WriteTo writes unsigned .dsc to w. [pkg/io.WriterTo] interface implementation
+func (d *Dsc) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if err := deb822.NewEncoder(&buf).Encode(*d); err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(w)
+}
+
+// Filename returns conventional name of the .dsc file, i.e. hello_2.10-3.dsc. Epoch is omitted
+func (d *Dsc) Filename() string {
+	version := d.Version
+	version.Epoch = 0
+	return fmt.Sprintf("%s_%s.dsc", d.Source, version)
+}
+
+/*
+New generates .dsc for the source package described by control. Version usually comes from debian/changelog and
+format from debian/source/format.
+
+Artifacts are paths to source tarballs (and their signatures), checksums and sizes are computed. They are listed
+the way dpkg-source does: upstream tarballs and their signatures sorted by name, then everything else in the given
+order.
+*/
+func New(control pkg.Control, version fields.Version, format string, artifacts ...string) (*Dsc, error) {
+	src := control.DebSrc
+
+	res := Dsc{
+		Format:              format,
+		Source:              src.Name,
+		Version:             version,
+		Maintainer:          src.Maintainer,
+		Uploaders:           src.Uploaders,
+		Homepage:            src.Homepage,
+		StandardsVersion:    src.StandardsVersion,
+		VcsBrowser:          src.VcsBrowser,
+		VcsGit:              src.VcsGit,
+		BuildDepends:        src.BuildDepends,
+		BuildDependsArch:    src.BuildDependsArch,
+		BuildDependsIndep:   src.BuildDependsIndep,
+		BuildConflicts:      src.BuildConflicts,
+		BuildConflictsArch:  src.BuildConflictsArch,
+		BuildConflictsIndep: src.BuildConflictsIndep,
+	}
+
+	if src.Testsuite != "" {
+		for _, suite := range strings.Split(src.Testsuite, ",") {
+			res.Testsuite = append(res.Testsuite, strings.TrimSpace(suite))
+		}
+	}
+
+	// other version control systems, i.e. Vcs-Svn
+	for name, value := range src.Extra {
+		if strings.HasPrefix(strings.ToLower(name), "vcs-") {
+			if res.Extra == nil {
+				res.Extra = make(map[string]string)
+			}
+			res.Extra[name] = value
+		}
+	}
+
+	var architectures []string
+	for _, bin := range control.Deb {
+		res.Binary = append(res.Binary, bin.Name)
+
+		arch := bin.Architecture.String()
+		if !slices.Contains(architectures, arch) {
+			architectures = append(architectures, arch)
+		}
+
+		entry := PackageListEntry{
+			Name:     bin.Name,
+			Type:     cmp.Or(bin.PackageType, "deb"),
+			Section:  cmp.Or(bin.Section, src.Section, "-"),
+			Priority: cmp.Or(bin.Priority, src.Priority, "-"),
+			Options:  []string{"arch=" + arch},
+		}
+		res.PackageList = append(res.PackageList, entry)
+	}
+
+	// architecture specific packages are covered by any, as dpkg-source does
+	if slices.Contains(architectures, "any") {
+		architectures = slices.DeleteFunc(architectures, func(arch string) bool { return arch != "any" && arch != "all" })
+	}
+	for _, arch := range architectures {
+		res.Architecture = append(res.Architecture, fields.MakeArch(arch))
+	}
+
+	artifacts = slices.Clone(artifacts)
+	slices.SortStableFunc(artifacts, func(a, b string) int {
+		a, b = filepath.Base(a), filepath.Base(b)
+		aOrig, bOrig := isOrig(a), isOrig(b)
+		switch {
+		case aOrig && bOrig:
+			return strings.Compare(a, b)
+		case aOrig:
+			return -1
+		case bOrig:
+			return 1
+		}
+		return 0
+	})
+
+	for _, artifact := range artifacts {
+		sums, size, err := checksums(artifact)
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Base(artifact)
+		res.Files = append(res.Files, File{sums[0], size, name})
+		res.ChecksumsSha1 = append(res.ChecksumsSha1, File{sums[1], size, name})
+		res.ChecksumsSha256 = append(res.ChecksumsSha256, File{sums[2], size, name})
+	}
+
+	return &res, nil
+}
+
+// upstream tarball or its signature, i.e. hello_1.0.orig.tar.gz or hello_1.0.orig-doc.tar.gz.asc
+func isOrig(name string) bool {
+	return strings.Contains(name, ".orig.tar") || strings.Contains(name, ".orig-")
+}
+
+var checksumNames = [3]string{"MD5", "SHA1", "SHA256"}
+
+// returns hex encoded MD5, SHA1 and SHA256 checksums and size of the file
+func checksums(name string) ([3]string, int64, error) {
+	var res [3]string
+
+	f, err := os.Open(name)
+	if err != nil {
+		return res, 0, err
+	}
+	defer f.Close()
+
+	hashes := []hash.Hash{md5.New(), sha1.New(), sha256.New()}
+	size, err := io.Copy(io.MultiWriter(hashes[0], hashes[1], hashes[2]), f)
+	if err != nil {
+		return res, 0, err
+	}
+
+	for idx, h := range hashes {
+		res[idx] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return res, size, nil
+}
+
+// FileError is a problem with a file referenced by .dsc
+type FileError struct {
+	Name string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Verify checks that files referenced by .dsc exist in dir and match sizes and every checksum listed (Files,
+Checksums-Sha1 and Checksums-Sha256). All problems found are reported as [*FileError]s joined together.
+*/
+func (d *Dsc) Verify(dir string) error {
+	var errs []error
+
+	names := make(map[string]bool)
+	for _, list := range [][]File{d.Files, d.ChecksumsSha1, d.ChecksumsSha256} {
+		for _, f := range list {
+			names[f.Name] = true
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		if filepath.Base(name) != name {
+			errs = append(errs, &FileError{name, errors.New("file name must not contain a path")})
+			continue
+		}
+
+		sums, size, err := checksums(filepath.Join(dir, name))
+		if err != nil {
+			errs = append(errs, &FileError{name, err})
+			continue
+		}
+
+		for idx, list := range [][]File{d.Files, d.ChecksumsSha1, d.ChecksumsSha256} {
+			pos := slices.IndexFunc(list, func(f File) bool { return f.Name == name })
+			if pos == -1 {
+				continue
+			}
+
+			if list[pos].Size != size {
+				errs = append(errs, &FileError{name, fmt.Errorf("size mismatch: expected %d, got %d", list[pos].Size, size)})
+				break
+			}
+
+			if !strings.EqualFold(list[pos].Checksum, sums[idx]) {
+				errs = append(errs, &FileError{name, fmt.Errorf("%s checksum mismatch", checksumNames[idx])})
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
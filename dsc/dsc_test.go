@@ -0,0 +1,183 @@
+package dsc_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/aol-nnov/debian/dsc"
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/pkg"
+)
+
+func ExampleFromStream() {
+	f, _ := os.Open("testdata/hello_1.0-1.dsc")
+	defer f.Close()
+
+	d, err := dsc.FromStream(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(d.Filename(), d.Format, d.Binary, d.Architecture)
+	for _, entry := range d.PackageList {
+		fmt.Println(entry.Name, entry.Section, entry.Options)
+	}
+	for _, f := range d.Files {
+		fmt.Println(f.Name, f.Size)
+	}
+
+	// Output:
+	// hello_1.0-1.dsc 3.0 (quilt) [hello hello-doc] [any all]
+	// hello devel [arch=any]
+	// hello-doc doc [arch=all]
+	// hello_1.0.orig-extra.tar.gz 160
+	// hello_1.0.orig.tar.gz 237
+	// hello_1.0-1.debian.tar.xz 956
+}
+
+func TestFromStreamSigned(t *testing.T) {
+	in, err := os.ReadFile("testdata/hello_1.0-1.dsc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entity, err := openpgp.NewEntity("Test", "", "test@example.net", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var signed bytes.Buffer
+	w, err := clearsign.Encode(&signed, entity.PrivateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(in)
+	w.Close()
+
+	d, err := dsc.FromStream(&signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Source != "hello" || len(d.ChecksumsSha256) != 3 || d.Signature == nil {
+		t.Fatalf("unexpected result %+v", d)
+	}
+
+	if _, err := d.Signature.Verify(openpgp.EntityList{entity}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	f, _ := os.Open("testdata/hello_1.0-1.dsc")
+	defer f.Close()
+
+	d, err := dsc.FromStream(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Verify("testdata"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	// same size, so checksums are compared
+	orig, _ := os.ReadFile("testdata/hello_1.0.orig.tar.gz")
+	tampered := bytes.Clone(orig)
+	tampered[len(tampered)-1] ^= 0xff
+	os.WriteFile(filepath.Join(dir, "hello_1.0.orig.tar.gz"), tampered, 0644)
+
+	// different size
+	os.WriteFile(filepath.Join(dir, "hello_1.0.orig-extra.tar.gz"), []byte("truncated"), 0644)
+
+	err = d.Verify(dir)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected missing debian tarball, got %v", err)
+	}
+
+	mismatches := map[string]string{}
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fe *dsc.FileError
+		if !errors.As(e, &fe) {
+			t.Fatalf("expected FileError, got %v", e)
+		}
+		if !errors.Is(fe.Err, fs.ErrNotExist) {
+			mismatches[fe.Name] = fe.Err.Error()
+		}
+	}
+
+	if !strings.Contains(mismatches["hello_1.0.orig.tar.gz"], "checksum mismatch") ||
+		!strings.Contains(mismatches["hello_1.0.orig-extra.tar.gz"], "size mismatch") {
+		t.Fatalf("expected checksum and size mismatches, got %v", mismatches)
+	}
+}
+
+func TestNew(t *testing.T) {
+	f, err := os.Open("testdata/control")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var control pkg.Control
+	if err := control.Decode(f); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := dsc.New(control, fields.MakeVersion("1.0-1"), "3.0 (quilt)",
+		"testdata/hello_1.0-1.debian.tar.xz", "testdata/hello_1.0.orig.tar.gz", "testdata/hello_1.0.orig-extra.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if _, err := d.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, _ := os.ReadFile("testdata/hello_1.0-1.dsc")
+	if out.String() != string(expected) {
+		t.Fatalf("generated .dsc differs from dpkg-source one:\n%s", out.String())
+	}
+}
+
+func TestNewSignatures(t *testing.T) {
+	dir := t.TempDir()
+	var artifacts []string
+	for _, name := range []string{"hello_1.0-1.debian.tar.xz", "hello_1.0.orig.tar.gz.asc", "hello_1.0.orig.tar.gz",
+		"hello_1.0.orig-extra.tar.gz.asc", "hello_1.0.orig-extra.tar.gz"} {
+		artifact := filepath.Join(dir, name)
+		if err := os.WriteFile(artifact, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	d, err := dsc.New(pkg.Control{}, fields.MakeVersion("1.0-1"), "3.0 (quilt)", artifacts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, f := range d.Files {
+		names = append(names, f.Name)
+	}
+
+	// as listed by dpkg-source -b
+	expected := []string{"hello_1.0.orig-extra.tar.gz", "hello_1.0.orig-extra.tar.gz.asc", "hello_1.0.orig.tar.gz",
+		"hello_1.0.orig.tar.gz.asc", "hello_1.0-1.debian.tar.xz"}
+	if !slices.Equal(names, expected) {
+		t.Fatalf("unexpected order %v", names)
+	}
+}
@@ -0,0 +1,297 @@
+package dsc
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aol-nnov/debian/internal/safepath"
+	"github.com/aol-nnov/debian/internal/universalreader"
+	"github.com/aol-nnov/debian/quilt"
+)
+
+// tarballs of a source package by their role
+type tarballs struct {
+	native     string            // the only tarball of a native package
+	orig       string            // upstream sources
+	components map[string]string // additional upstream tarballs by component name, i.e. orig-doc.tar.gz
+	debian     string            // packaging
+}
+
+func (d *Dsc) tarballs() (tarballs, error) {
+	res := tarballs{components: make(map[string]string)}
+
+	for _, f := range d.Files {
+		name := f.Name
+		if !strings.Contains(name, ".tar") || strings.HasSuffix(name, ".asc") {
+			continue
+		}
+
+		var role *string
+		switch {
+		case strings.Contains(name, ".orig-"):
+			_, comp, _ := strings.Cut(name, ".orig-")
+			comp, _, _ = strings.Cut(comp, ".tar")
+			if comp == "" || comp == "debian" || strings.ContainsAny(comp, "/.") {
+				return res, fmt.Errorf("%s: invalid component name '%s'", name, comp)
+			}
+			if _, found := res.components[comp]; found {
+				return res, fmt.Errorf("%s: duplicate component %s", name, comp)
+			}
+			res.components[comp] = name
+			continue
+		case strings.Contains(name, ".orig.tar"):
+			role = &res.orig
+		case strings.Contains(name, ".debian.tar"):
+			role = &res.debian
+		default:
+			role = &res.native
+		}
+
+		if *role != "" {
+			return res, fmt.Errorf("%s: more than one tarball of the same kind, %s is already there", name, *role)
+		}
+		*role = name
+	}
+
+	return res, nil
+}
+
+/*
+Extract unpacks the source package, which files reside in dir, to target directory like dpkg-source -x does. Target
+must not exist. Files are verified against checksums listed in .dsc before unpacking.
+
+For "3.0 (native)" the only tarball is unpacked. For "3.0 (quilt)" the orig tarball is unpacked, component tarballs
+go to the subdirectories named after the components, upstream debian directory is replaced with the contents of the
+debian tarball and patches listed in debian/patches/series are applied. Unlike dpkg-source, no .pc directory is
+created, so the result is not suitable for quilt itself.
+
+The single top level directory of upstream tarballs is stripped. Entries leading outside of target (absolute, with
+".." components or through symbolic links) are rejected, as are patches touching such paths. Other source formats
+are not supported.
+*/
+func (d *Dsc) Extract(dir, target string) error {
+	if err := d.Verify(dir); err != nil {
+		return err
+	}
+
+	archives, err := d.tarballs()
+	if err != nil {
+		return err
+	}
+
+	format := strings.TrimSpace(d.Format)
+	switch format {
+	case "3.0 (native)":
+		if archives.native == "" || archives.orig != "" || archives.debian != "" || len(archives.components) > 0 {
+			return fmt.Errorf("format %s requires a single tarball", format)
+		}
+	case "3.0 (quilt)":
+		if archives.orig == "" || archives.debian == "" || archives.native != "" {
+			return fmt.Errorf("format %s requires orig and debian tarballs", format)
+		}
+	default:
+		return fmt.Errorf("unsupported source format '%s'", format)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if err := os.Mkdir(target, 0o755); err != nil {
+		return err
+	}
+
+	if format == "3.0 (native)" {
+		return untar(filepath.Join(dir, archives.native), target, true)
+	}
+
+	if err := untar(filepath.Join(dir, archives.orig), target, true); err != nil {
+		return err
+	}
+
+	for comp, name := range archives.components {
+		compDir := filepath.Join(target, comp)
+		if err := os.RemoveAll(compDir); err != nil {
+			return err
+		}
+		if err := os.Mkdir(compDir, 0o755); err != nil {
+			return err
+		}
+		if err := untar(filepath.Join(dir, name), compDir, true); err != nil {
+			return err
+		}
+	}
+
+	// upstream packaging is replaced, not merged
+	if err := os.RemoveAll(filepath.Join(target, "debian")); err != nil {
+		return err
+	}
+	if err := untar(filepath.Join(dir, archives.debian), target, false); err != nil {
+		return err
+	}
+
+	return quilt.ApplySeries(target)
+}
+
+// openTar opens possibly compressed tarball
+func openTar(name string) (*tar.Reader, io.Closer, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if strings.HasSuffix(name, ".tar") {
+		return tar.NewReader(f), f, nil
+	}
+
+	reader, err := universalreader.Decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return tar.NewReader(reader), reader.(io.Closer), nil
+}
+
+// topDir returns the directory all entries of the tarball reside in, if there is a single one
+func topDir(name string) (string, error) {
+	tr, closer, err := openTar(name)
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+
+	top := ""
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return top, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", filepath.Base(name), err)
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		first, rest, found := strings.Cut(strings.TrimPrefix(hdr.Name, "./"), "/")
+		if !found && hdr.Typeflag != tar.TypeDir || top != "" && first != top || first == ".." {
+			return "", nil
+		}
+		if rest == "" && hdr.Typeflag != tar.TypeDir {
+			return "", nil
+		}
+		top = first
+	}
+}
+
+// untar unpacks the tarball into target, optionally stripping its single top level directory
+func untar(name, target string, stripTop bool) error {
+	top := ""
+	if stripTop {
+		var err error
+		if top, err = topDir(name); err != nil {
+			return err
+		}
+	}
+
+	tr, closer, err := openTar(name)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err == nil {
+			err = extractEntry(tr, hdr, target, top)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(name), err)
+		}
+	}
+}
+
+func extractEntry(tr *tar.Reader, hdr *tar.Header, target, top string) error {
+	if hdr.Typeflag == tar.TypeXGlobalHeader {
+		return nil
+	}
+
+	entryName := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+	if top != "" {
+		if entryName == top {
+			return nil
+		}
+		entryName = strings.TrimPrefix(entryName, top+"/")
+	}
+
+	dest, err := safepath.Join(target, entryName)
+	if err != nil {
+		return err
+	}
+	if dest == target {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	// later entries replace earlier ones, symbolic links must not be followed
+	if hdr.Typeflag != tar.TypeDir {
+		if err := os.Remove(dest); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	mode := fs.FileMode(hdr.Mode).Perm()
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if info, err := os.Lstat(dest); err == nil && !info.IsDir() {
+			if err := os.Remove(dest); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(dest, 0o755); err != nil {
+			return err
+		}
+		return os.Chmod(dest, mode|0o700)
+	case tar.TypeReg:
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		return os.Chtimes(dest, hdr.ModTime, hdr.ModTime)
+	case tar.TypeSymlink:
+		return os.Symlink(hdr.Linkname, dest)
+	case tar.TypeLink:
+		linkName := path.Clean(strings.TrimPrefix(hdr.Linkname, "./"))
+		if top != "" {
+			linkName = strings.TrimPrefix(linkName, top+"/")
+		}
+		source, err := safepath.Join(target, linkName)
+		if err != nil {
+			return err
+		}
+		return os.Link(source, dest)
+	default:
+		return fmt.Errorf("%s: unsupported entry type %c", hdr.Name, hdr.Typeflag)
+	}
+}
@@ -0,0 +1,135 @@
+package dsc_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/dsc"
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/internal/safepath"
+)
+
+func ExampleDsc_Extract() {
+	f, _ := os.Open("testdata/hello_1.0-1.dsc")
+	defer f.Close()
+
+	d, err := dsc.FromStream(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	target, _ := os.MkdirTemp("", "extract")
+	defer os.RemoveAll(target)
+
+	if err := d.Extract("testdata", filepath.Join(target, "hello-1.0")); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, name := range []string{"greeting.txt", "src/main.c", "extra/data.txt"} {
+		content, _ := os.ReadFile(filepath.Join(target, "hello-1.0", name))
+		fmt.Printf("%s:\n%s", name, content)
+	}
+
+	// Output:
+	// greeting.txt:
+	// hello
+	// universe
+	// third line
+	// src/main.c:
+	// /* patched */
+	// int main() { return 1; }
+	// extra/data.txt:
+	// component data
+}
+
+// nativeDsc writes a native source package with a tarball of given entries to dir
+func nativeDsc(t *testing.T, dir string, entries ...tar.Header) *dsc.Dsc {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range entries {
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(bytes.Repeat([]byte{'x'}, int(hdr.Size)))
+	}
+	tw.Close()
+
+	const name = "evil_1.0.tar"
+	if err := os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := md5.Sum(buf.Bytes())
+	return &dsc.Dsc{
+		Format:  "3.0 (native)",
+		Source:  "evil",
+		Version: fields.MakeVersion("1.0"),
+		Files:   []dsc.File{{Checksum: hex.EncodeToString(sum[:]), Size: int64(buf.Len()), Name: name}},
+	}
+}
+
+func TestExtractOutside(t *testing.T) {
+	cases := map[string][]tar.Header{
+		"parent":   {{Name: "../escaped", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}},
+		"absolute": {{Name: "/tmp/escaped", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}},
+		"symlink": {
+			{Name: "link", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			{Name: "link/escaped", Typeflag: tar.TypeReg, Size: 1, Mode: 0644},
+		},
+		"hardlink": {{Name: "passwd", Typeflag: tar.TypeLink, Linkname: "../../../etc/passwd"}},
+	}
+
+	for name, entries := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			d := nativeDsc(t, dir, entries...)
+
+			err := d.Extract(dir, filepath.Join(dir, "out"))
+			if !errors.Is(err, safepath.ErrOutside) {
+				t.Fatalf("expected %v, got %v", safepath.ErrOutside, err)
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, "escaped")); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatal("file written outside of the target directory")
+			}
+		})
+	}
+}
+
+func TestExtractNative(t *testing.T) {
+	dir := t.TempDir()
+	d := nativeDsc(t, dir,
+		tar.Header{Name: "evil-1.0/", Typeflag: tar.TypeDir, Mode: 0755},
+		tar.Header{Name: "evil-1.0/bin/run", Typeflag: tar.TypeReg, Size: 3, Mode: 0755},
+		tar.Header{Name: "evil-1.0/run", Typeflag: tar.TypeSymlink, Linkname: "bin/run"},
+	)
+
+	target := filepath.Join(dir, "out")
+	if err := d.Extract(dir, target); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(target, "run"))
+	if err != nil || string(content) != "xxx" {
+		t.Fatalf("unexpected content %q, %v", content, err)
+	}
+
+	if info, _ := os.Stat(filepath.Join(target, "bin/run")); info.Mode().Perm() != 0755 {
+		t.Fatalf("mode not preserved: %v", info.Mode())
+	}
+
+	if err := d.Extract(dir, target); err == nil || !strings.Contains(err.Error(), "exists") {
+		t.Fatalf("existing target must be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotModified is returned by [ConditionalFetcher], when the resource matches the validators
+var ErrNotModified = errors.New("not modified")
+
+// Validators of a cached resource, as used by HTTP conditional requests
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// ConditionalFetcher is implemented by fetchers, which are able to revalidate cached resources
+type ConditionalFetcher interface {
+	Fetcher
+
+	// FetchIfModified retrieves the resource at url, unless it matches cached validators, in which case
+	// [ErrNotModified] is returned. Validators of the fetched resource are returned along with it
+	FetchIfModified(ctx context.Context, url string, cached Validators) (io.ReadCloser, Validators, error)
+}
+
+// HashFetcher is implemented by fetchers, which are able to serve a resource with a known SHA256 without a round trip
+type HashFetcher interface {
+	Fetcher
+
+	FetchHash(ctx context.Context, url, sha256 string) (io.ReadCloser, error)
+}
+
+/*
+Cache stores resources fetched by the underlying fetcher in a directory, keyed by url.
+
+Cached copies are revalidated, if the underlying fetcher is a [ConditionalFetcher], and served without any request at
+all by [Cache.FetchHash], if the expected hash matches. A resource is stored only if it was read to the end.
+
+Contents are stored under their hash and metadata refers to them, so replacing metadata with a single rename commits
+the new copy: readers see either the old contents with old validators or the new ones with new validators.
+*/
+type Cache struct {
+	Dir     string
+	Fetcher Fetcher
+}
+
+// metadata stored next to the cached resource
+type cacheMeta struct {
+	URL        string
+	SHA256     string
+	Validators Validators
+}
+
+func (c *Cache) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	conditional, ok := c.Fetcher.(ConditionalFetcher)
+	if !ok {
+		reader, err := c.Fetcher.Fetch(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		return c.store(url, reader, Validators{})
+	}
+
+	var cached Validators
+	meta, metaErr := c.meta(url)
+	if metaErr == nil {
+		cached = meta.Validators
+	}
+
+	reader, validators, err := conditional.FetchIfModified(ctx, url, cached)
+	if errors.Is(err, ErrNotModified) {
+		// nothing has been cached, but fetcher may still claim it is not modified
+		if metaErr == nil {
+			if reader, err := os.Open(c.dataPath(url, meta.SHA256)); err == nil {
+				return reader, nil
+			}
+		}
+
+		// cached copy has gone, let's fetch it unconditionally
+		reader, validators, err = conditional.FetchIfModified(ctx, url, Validators{})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.store(url, reader, validators)
+}
+
+// FetchHash serves cached copy of the resource, if its SHA256 matches, falls back to [Cache.Fetch] otherwise
+func (c *Cache) FetchHash(ctx context.Context, url, sha256 string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if meta, err := c.meta(url); err == nil && meta.SHA256 == sha256 {
+		if reader, err := os.Open(c.dataPath(url, meta.SHA256)); err == nil {
+			return reader, nil
+		}
+	}
+
+	return c.Fetch(ctx, url)
+}
+
+func (c *Cache) path(url string) string {
+	key := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, hex.EncodeToString(key[:]))
+}
+
+// contents of the resource are stored under their hash
+func (c *Cache) dataPath(url, sha256 string) string {
+	return c.path(url) + "-" + sha256
+}
+
+func (c *Cache) meta(url string) (*cacheMeta, error) {
+	data, err := os.ReadFile(c.path(url) + ".json")
+	if err != nil {
+		return nil, err
+	}
+
+	var meta cacheMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+
+	if meta.URL != url {
+		return nil, os.ErrNotExist
+	}
+
+	return &meta, nil
+}
+
+// tees reader into a temporary file, which becomes the cached copy when the reader is consumed
+func (c *Cache) store(url string, reader io.ReadCloser, validators Validators) (io.ReadCloser, error) {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(c.Dir, "partial-*")
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	return &cacheWriter{
+		cache:      c,
+		url:        url,
+		validators: validators,
+		src:        reader,
+		tmp:        tmp,
+		hash:       sha256.New(),
+	}, nil
+}
+
+type cacheWriter struct {
+	cache      *Cache
+	url        string
+	validators Validators
+	src        io.ReadCloser
+	tmp        *os.File
+	hash       hash.Hash
+	failed     bool // cached copy is incomplete
+	complete   bool // source was read to the end
+}
+
+func (w *cacheWriter) Read(p []byte) (int, error) {
+	n, err := w.src.Read(p)
+
+	if n > 0 && !w.failed {
+		if _, writeErr := w.tmp.Write(p[:n]); writeErr != nil {
+			w.failed = true
+		}
+		w.hash.Write(p[:n])
+	}
+
+	switch {
+	case err == io.EOF:
+		w.complete = true
+	case err != nil:
+		w.failed = true
+	}
+
+	return n, err
+}
+
+// Close closes the source and commits the cached copy, if it is complete
+func (w *cacheWriter) Close() error {
+	err := w.src.Close()
+
+	w.tmp.Close()
+	if w.failed || !w.complete || w.commit() != nil {
+		os.Remove(w.tmp.Name())
+	}
+
+	return err
+}
+
+// contents are put in place first, then metadata referring to them replaces the old one with a single rename
+func (w *cacheWriter) commit() error {
+	path := w.cache.path(w.url)
+	sum := hex.EncodeToString(w.hash.Sum(nil))
+
+	if err := os.Rename(w.tmp.Name(), w.cache.dataPath(w.url, sum)); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(cacheMeta{
+		URL:        w.url,
+		SHA256:     sum,
+		Validators: w.validators,
+	})
+	if err != nil {
+		return err
+	}
+
+	previous, previousErr := w.cache.meta(w.url)
+
+	tmpMeta, err := os.CreateTemp(w.cache.Dir, "partial-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmpMeta.Write(meta)
+	if closeErr := tmpMeta.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpMeta.Name(), path+".json")
+	}
+	if err != nil {
+		os.Remove(tmpMeta.Name())
+		return err
+	}
+
+	// contents, which are not referred anymore
+	if previousErr == nil && previous.SHA256 != sum {
+		os.Remove(w.cache.dataPath(w.url, previous.SHA256))
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package fetch_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aol-nnov/debian/fetch"
+)
+
+func TestCache(t *testing.T) {
+	const body = "Package: hello\n"
+	var requests, downloads int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	cache := &fetch.Cache{Dir: t.TempDir(), Fetcher: fetch.Default()}
+	url := server.URL + "/Packages"
+
+	read := func(reader io.ReadCloser, err error) string {
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+
+		data, _ := io.ReadAll(reader)
+		return string(data)
+	}
+
+	// partially read resource is not cached
+	reader, _ := cache.Fetch(context.Background(), url)
+	reader.Read(make([]byte, 1))
+	reader.Close()
+
+	for range 2 {
+		if data := read(cache.Fetch(context.Background(), url)); data != body {
+			t.Fatalf("unexpected data '%s'", data)
+		}
+	}
+
+	if requests != 3 || downloads != 2 {
+		t.Fatalf("cached copy must be revalidated, got %d requests and %d downloads", requests, downloads)
+	}
+
+	if data := read(cache.FetchHash(context.Background(), url, fmt.Sprintf("%x", sha256.Sum256([]byte(body))))); data != body {
+		t.Fatalf("unexpected data '%s'", data)
+	}
+
+	if requests != 3 {
+		t.Fatal("cached copy with matching hash must be served without a request")
+	}
+
+	read(cache.FetchHash(context.Background(), url, "0000"))
+
+	if requests != 4 {
+		t.Fatal("hash mismatch must be revalidated")
+	}
+}
+
+func TestCacheReplace(t *testing.T) {
+	version := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, version))
+		fmt.Fprintf(w, "Version: %d\n", version)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cache := &fetch.Cache{Dir: dir, Fetcher: fetch.Default()}
+	url := server.URL + "/Packages"
+
+	for ; version <= 2; version++ {
+		reader, err := cache.Fetch(context.Background(), url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.ReadAll(reader)
+		reader.Close()
+	}
+
+	// metadata and contents of the second version only
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("stale cache entries left: %v", entries)
+	}
+
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte("Version: 2\n")))
+	reader, err := cache.FetchHash(context.Background(), url, sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	if data, _ := io.ReadAll(reader); string(data) != "Version: 2\n" {
+		t.Fatalf("unexpected data '%s'", data)
+	}
+}
+
+// claims every resource is not modified
+type notModifiedFetcher struct{}
+
+func (notModifiedFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	return nil, fetch.ErrNotModified
+}
+
+func (notModifiedFetcher) FetchIfModified(ctx context.Context, url string, cached fetch.Validators) (io.ReadCloser, fetch.Validators, error) {
+	return nil, cached, fetch.ErrNotModified
+}
+
+func TestCacheNotModifiedEmpty(t *testing.T) {
+	cache := &fetch.Cache{Dir: t.TempDir(), Fetcher: notModifiedFetcher{}}
+
+	if _, err := cache.Fetch(context.Background(), "http://example.org/Packages"); !errors.Is(err, fetch.ErrNotModified) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Package fetch provides pluggable transports used to retrieve repository files.
+
+[Default] returns a fetcher, which handles http(s):// urls, file:// urls and plain local paths. [Memory] is
+an in-memory fake for tests. [Cache] keeps fetched resources on disk.
+
+Missing resources are reported as [io/fs.ErrNotExist] by every implementation, so callers may fall back to
+alternatives with errors.Is.
+*/
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Fetcher retrieves the resource at url. The caller must close the result
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// Mux dispatches urls to fetchers by scheme. Urls without a scheme are dispatched with an empty one
+type Mux map[string]Fetcher
+
+func (m Mux) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	fetcher, err := m.fetcher(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.Fetch(ctx, url)
+}
+
+// FetchIfModified dispatches to [ConditionalFetcher]s, others just fetch the resource
+func (m Mux) FetchIfModified(ctx context.Context, url string, cached Validators) (io.ReadCloser, Validators, error) {
+	fetcher, err := m.fetcher(url)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+
+	if conditional, ok := fetcher.(ConditionalFetcher); ok {
+		return conditional.FetchIfModified(ctx, url, cached)
+	}
+
+	reader, err := fetcher.Fetch(ctx, url)
+	return reader, Validators{}, err
+}
+
+func (m Mux) fetcher(url string) (Fetcher, error) {
+	scheme, _, found := strings.Cut(url, "://")
+	if !found {
+		scheme = ""
+	}
+
+	if fetcher, found := m[scheme]; found {
+		return fetcher, nil
+	}
+
+	return nil, fmt.Errorf("fetch: unsupported scheme '%s' in %s", scheme, url)
+}
+
+// Default returns [Mux] with [HTTP] for http(s):// urls and [File] for file:// urls and local paths
+func Default() Mux {
+	httpFetcher := &HTTP{}
+
+	return Mux{
+		"http":  httpFetcher,
+		"https": httpFetcher,
+		"file":  File{},
+		"":      File{},
+	}
+}
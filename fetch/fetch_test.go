@@ -0,0 +1,111 @@
+package fetch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aol-nnov/debian/fetch"
+)
+
+func TestHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, passwd, _ := r.BasicAuth()
+		if user != "user" || passwd != "secret" || r.Header.Get("X-Test") != "yes" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/Release":
+			fmt.Fprint(w, "Origin: Test\n")
+		case "/slow":
+			time.Sleep(time.Second)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := &fetch.HTTP{
+		Header:   http.Header{"X-Test": {"yes"}},
+		Username: "user",
+		Password: "secret",
+		Timeout:  100 * time.Millisecond,
+	}
+
+	reader, err := fetcher.Fetch(context.Background(), server.URL+"/Release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+
+	if string(data) != "Origin: Test\n" {
+		t.Fatalf("unexpected response '%s'", data)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("404 must be reported as fs.ErrNotExist, got %v", err)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timeout expected, got %v", err)
+	}
+
+	if _, err := (&fetch.HTTP{}).Fetch(context.Background(), server.URL+"/Release"); err == nil {
+		t.Fatal("request without credentials must fail")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Release")
+	os.WriteFile(path, []byte("Origin: Test\n"), 0o644)
+
+	for _, url := range []string{path, "file://" + path} {
+		reader, err := fetch.Default().Fetch(context.Background(), url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader.Close()
+	}
+
+	if _, err := fetch.Default().Fetch(context.Background(), path+".gz"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("missing file must be reported as fs.ErrNotExist, got %v", err)
+	}
+
+	if _, err := fetch.Default().Fetch(context.Background(), "ftp://example.net/Release"); err == nil {
+		t.Fatal("unsupported scheme must fail")
+	}
+}
+
+func TestMemoryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := (fetch.Memory{"Release": nil}).Fetch(ctx, "Release"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled context must be respected, got %v", err)
+	}
+}
+
+func ExampleMemory() {
+	fetcher := fetch.Memory{
+		"http://deb.example.net/debian/dists/stable/Release": []byte("Origin: Example\n"),
+	}
+
+	reader, _ := fetcher.Fetch(context.Background(), "http://deb.example.net/debian/dists/stable/Release")
+	defer reader.Close()
+
+	data, _ := io.ReadAll(reader)
+	fmt.Print(string(data))
+
+	// Output: Origin: Example
+}
@@ -0,0 +1,19 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+)
+
+// File fetches local files, either by file:// url or by plain path
+type File struct{}
+
+func (File) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return os.Open(strings.TrimPrefix(url, "file://"))
+}
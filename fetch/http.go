@@ -0,0 +1,109 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// HTTP fetches http(s):// urls. The zero value uses [net/http.DefaultClient] and no timeout
+type HTTP struct {
+	Client  *http.Client  // defaults to http.DefaultClient
+	Timeout time.Duration // whole request timeout, body reading included. Zero means no timeout
+	Header  http.Header   // extra request headers
+
+	// basic auth credentials, used if Username is set
+	Username string
+	Password string
+}
+
+func (h *HTTP) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	reader, _, err := h.FetchIfModified(ctx, url, Validators{})
+	return reader, err
+}
+
+// FetchIfModified sends If-None-Match and If-Modified-Since headers. [ConditionalFetcher] interface implementation
+func (h *HTTP) FetchIfModified(ctx context.Context, url string, cached Validators) (io.ReadCloser, Validators, error) {
+	cancel := context.CancelFunc(func() {})
+	if h.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+	}
+
+	header := make(http.Header)
+	if cached.ETag != "" {
+		header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		header.Set("If-Modified-Since", cached.LastModified)
+	}
+
+	res, err := h.get(ctx, url, header)
+	if err != nil {
+		cancel()
+		return nil, Validators{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		cancel()
+
+		if res.StatusCode == http.StatusNotModified {
+			return nil, cached, ErrNotModified
+		}
+		return nil, Validators{}, statusError(url, res)
+	}
+
+	validators := Validators{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+
+	return &cancelOnClose{res.Body, cancel}, validators, nil
+}
+
+// performs GET request with configured headers and credentials. Response is returned whatever the status code is
+func (h *HTTP) get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range h.Header {
+		req.Header[name] = values
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	if h.Username != "" {
+		req.SetBasicAuth(h.Username, h.Password)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return client.Do(req)
+}
+
+func statusError(url string, res *http.Response) error {
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone {
+		return fmt.Errorf("%s: %s: %w", url, res.Status, fs.ErrNotExist)
+	}
+	return fmt.Errorf("%s: %s", url, res.Status)
+}
+
+// releases request context, when response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,25 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// Memory serves resources from a map of url to contents. Intended for tests
+type Memory map[string][]byte
+
+func (m Memory) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, found := m[url]
+	if !found {
+		return nil, fmt.Errorf("%s: %w", url, fs.ErrNotExist)
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
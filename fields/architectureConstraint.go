@@ -1,5 +1,7 @@
 package fields
 
+import "slices"
+
 /*
 Architecture constraints are used in different fields of Debian control file, mostly, in Build-Depends
 
@@ -38,6 +40,11 @@ func (constraints ArchitectureConstraints) SatisfiedBy(a Architecture) bool {
 		return true
 	}
 
+	// negated list (i.e. [!amd64 !i386]) excludes every listed architecture, so its constraints are AND-ed
+	if !slices.ContainsFunc(constraints, func(ac architectureConstraint) bool { return !ac.Negate }) {
+		return !slices.ContainsFunc(constraints, func(ac architectureConstraint) bool { return !ac.satisfiedBy(a) })
+	}
+
 	satisfied := false
 
 	for _, architectureConstraint := range constraints {
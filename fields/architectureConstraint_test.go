@@ -162,3 +162,13 @@ func TestConstraintsSatisfyReverse(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// ['!amd64', '!i386'] should NOT satisfy 'amd64'
+func TestNegListAcArch(t *testing.T) {
+	var ac fields.ArchitectureConstraints
+	ac.UnmarshalText([]byte("[!amd64 !i386]"))
+
+	if ac.SatisfiedBy(fields.MakeArch("amd64")) || !ac.SatisfiedBy(fields.MakeArch("armhf")) {
+		t.Fail()
+	}
+}
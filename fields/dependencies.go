@@ -2,12 +2,13 @@ package fields
 
 type Dependencies []Dependency
 
+// For returns dependencies, which apply to the build, with alternatives reduced as per [Dependency.Reduce]
 func (d Dependencies) For(buildArch Architecture, hostArch Architecture, profiles []string) Dependencies {
 	var res Dependencies
 
 	for _, dep := range d {
-		if dep.Satisfies(buildArch, hostArch, profiles) {
-			res = append(res, dep)
+		if reduced, ok := dep.Reduce(hostArch, profiles); ok {
+			res = append(res, reduced)
 		}
 	}
 	return res
@@ -16,10 +17,8 @@ func (d Dependencies) For(buildArch Architecture, hostArch Architecture, profile
 func (d Dependencies) NamesFor(buildArch Architecture, hostArch Architecture, profiles []string) []string {
 	var res []string
 
-	for _, dep := range d {
-		if dep.Satisfies(buildArch, hostArch, profiles) {
-			res = append(res, dep.Name)
-		}
+	for _, dep := range d.For(buildArch, hostArch, profiles) {
+		res = append(res, dep.Name)
 	}
 	return res
 }
@@ -54,12 +54,154 @@ Terminology:
   - TARGET (is only relevant for compilers and is the architecture that a compiler outputs code for. Unless packaging binutils, gcc or hurd, the target architecture is irrelevant.)
 
 This somewhat confusing terminology is GNU's fault. :clown_face:
+
+Satisfies reports whether the dependency applies to the build, i.e. architecture restrictions and restriction formulas
+of at least one alternative are satisfied by hostArch and active profiles. Use [Dependency.Reduce] to drop the
+alternatives, which do not apply, and [Dependency.SatisfiedBy] to check if a package satisfies the dependency itself.
 */
 func (dep Dependency) Satisfies(buildArch Architecture, hostArch Architecture, profiles []string) bool {
-	dc := true
-	ac := dep.ArchitectureConstraints.SatisfiedBy(hostArch)
-	pc := dep.ProfileConstraints.SatisfiedBy(profiles)
+	for alt := &dep; alt != nil; alt = alt.Alt {
+		if alt.ArchitectureConstraints.SatisfiedBy(hostArch) && alt.ProfileConstraints.SatisfiedBy(profiles) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Reduce drops alternatives, which restrictions do not apply to the build, like dpkg-checkbuilddeps does: architecture
+restrictions are checked against hostArch (unless it is the zero Architecture) and restriction formulas against
+active profiles. False is returned if no alternative is left, i.e. `foo [amd64] | bar [i386]` reduces to `bar` on
+i386 and to nothing on arm64.
+*/
+func (dep Dependency) Reduce(hostArch Architecture, profiles []string) (Dependency, bool) {
+	var res Dependency
+	tail := &res
+
+	found := false
+	for alt := &dep; alt != nil; alt = alt.Alt {
+		if hostArch != (Architecture{}) && !alt.ArchitectureConstraints.SatisfiedBy(hostArch) ||
+			!alt.ProfileConstraints.SatisfiedBy(profiles) {
+			continue
+		}
+
+		if found {
+			tail.Alt = &Dependency{}
+			tail = tail.Alt
+		}
+		*tail = *alt
+		tail.Alt = nil
+		found = true
+	}
+
+	return res, found
+}
+
+// Candidate is a binary package, which may satisfy a [Dependency]
+type Candidate struct {
+	Name         string
+	Version      Version
+	Architecture Architecture
+	MultiArch    MultiArch
+	Provides     Dependencies
+}
+
+/*
+SatisfiedBy reports whether candidate c satisfies the dependency or any of its alternatives. Restrictions are not
+considered here, see [Dependency.Satisfies].
+
+hostArch is the architecture the dependency is declared for (architecture of the depending package or the host
+architecture for build dependencies), buildArch is the native architecture of the system, which is used for `:native`
+qualifier and `Architecture: all` packages.
+
+Following dpkg rules apply:
+
+  - version constraint is checked against candidate version;
+  - virtual packages are satisfied by Provides, versioned dependencies only by versioned Provides (`foo (= 1.0)`);
+  - unqualified dependency is satisfied by a package of hostArch or by any `Multi-Arch: foreign` package;
+  - `:any` is satisfied by `Multi-Arch: allowed` package of any architecture;
+  - `:native` is satisfied by a package of buildArch.
+*/
+func (dep Dependency) SatisfiedBy(c Candidate, buildArch Architecture, hostArch Architecture) bool {
+	return dep.matches(c, buildArch, hostArch, false)
+}
+
+/*
+ConflictsWith reports whether candidate c is matched by the dependency used as Conflicts, Breaks or Replaces (or their
+build counterparts). The rules are the ones of [Dependency.SatisfiedBy] except `:any`, which matches a package of any
+architecture regardless of its Multi-Arch field, as dpkg does.
+*/
+func (dep Dependency) ConflictsWith(c Candidate, buildArch Architecture, hostArch Architecture) bool {
+	return dep.matches(c, buildArch, hostArch, true)
+}
+
+func (dep Dependency) matches(c Candidate, buildArch Architecture, hostArch Architecture, conflict bool) bool {
+	for alt := &dep; alt != nil; alt = alt.Alt {
+		if alt.satisfiedBy(c, buildArch, hostArch, conflict) {
+			return true
+		}
+	}
+	return false
+}
+
+// single alternative check
+func (dep Dependency) satisfiedBy(c Candidate, buildArch Architecture, hostArch Architecture, conflict bool) bool {
+	if dep.raw != "" || !dep.archSatisfiedBy(c, buildArch, hostArch, conflict) {
+		return false
+	}
+
+	if c.Name == dep.Name &&
+		(dep.VersionConstraint == nil || dep.VersionConstraint.SatisfiedBy(c.Version)) {
+		return true
+	}
+
+	for _, provided := range c.Provides {
+		if provided.Name != dep.Name {
+			continue
+		}
+
+		if dep.VersionConstraint == nil || dep.VersionConstraint.Op == VersionConstraintNotSet {
+			return true
+		}
+
+		if provided.VersionConstraint != nil && provided.VersionConstraint.Op == VersionConstraintEqual &&
+			dep.VersionConstraint.SatisfiedBy(provided.VersionConstraint.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// see archsatisfied() in dpkg/lib/dpkg/depcon.c
+func (dep Dependency) archSatisfiedBy(c Candidate, buildArch Architecture, hostArch Architecture, conflict bool) bool {
+	all := MakeArch("all")
+
+	if dep.ArchQualifier == "" && c.MultiArch == MultiArchForeign {
+		return true
+	}
+
+	if dep.ArchQualifier == "any" {
+		return conflict || c.MultiArch == MultiArchAllowed
+	}
+
+	depArch := hostArch
+	switch dep.ArchQualifier {
+	case "":
+	case "native":
+		depArch = buildArch
+	default:
+		depArch = MakeArch(dep.ArchQualifier)
+	}
+
+	if depArch == all {
+		depArch = buildArch
+	}
+
+	pkgArch := c.Architecture
+	if pkgArch == all {
+		pkgArch = buildArch
+	}
 
-	// fmt.Printf("%s: ac %v, pc %v\n", dep.Name, ac, pc)
-	return dc && ac && pc
+	return depArch.Equals(pkgArch)
 }
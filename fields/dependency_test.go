@@ -1,6 +1,7 @@
 package fields
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -112,3 +113,118 @@ func TestDepencencyVerArchProfiles(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestDependencySatisfiedBy(t *testing.T) {
+	amd64, i386 := MakeArch("amd64"), MakeArch("i386")
+
+	var provides Dependencies
+	for _, p := range []string{"mail-transport-agent", "libfoo-abi (= 2.0)"} {
+		var d Dependency
+		d.UnmarshalText([]byte(p))
+		provides = append(provides, d)
+	}
+
+	libfoo := Candidate{Name: "libfoo", Version: MakeVersion("2.0-1"), Architecture: i386, MultiArch: MultiArchSame, Provides: provides}
+	python := Candidate{Name: "python3", Version: MakeVersion("3.11.2-1"), Architecture: amd64, MultiArch: MultiArchAllowed}
+	gnuMake := Candidate{Name: "make", Version: MakeVersion("4.3-4"), Architecture: amd64, MultiArch: MultiArchForeign}
+	data := Candidate{Name: "foo-data", Version: MakeVersion("1.0-1"), Architecture: MakeArch("all")}
+
+	testCases := []struct {
+		dep       string
+		candidate Candidate
+		hostArch  Architecture
+		expected  bool
+	}{
+		{"libfoo (>= 2.0)", libfoo, i386, true},
+		{"libfoo (>> 2.0-1)", libfoo, i386, false},
+		{"libfoo", libfoo, amd64, false},     // M-A: same is not satisfied by another architecture
+		{"libfoo:i386", libfoo, amd64, true}, // unless architecture is explicit
+		{"mail-transport-agent", libfoo, i386, true},
+		{"mail-transport-agent (>= 1.0)", libfoo, i386, false}, // unversioned Provides
+		{"libfoo-abi (>= 2.0)", libfoo, i386, true},
+		{"libfoo-abi (<< 2.0)", libfoo, i386, false},
+		{"python3:any", python, i386, true},
+		{"python3", python, i386, false},
+		{"make:any", gnuMake, i386, false}, // :any requires M-A: allowed
+		{"make", gnuMake, i386, true},
+		{"make:native", gnuMake, i386, true},
+		{"foo-data", data, amd64, true},
+		{"foo-data", data, i386, false},
+		{"missing | make (>= 4.0)", gnuMake, i386, true},
+		{"missing | make (>= 5.0)", gnuMake, i386, false},
+	}
+
+	for _, tc := range testCases {
+		var dep Dependency
+		if err := dep.UnmarshalText([]byte(tc.dep)); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := dep.SatisfiedBy(tc.candidate, amd64, tc.hostArch); actual != tc.expected {
+			t.Errorf("%s by %s on %s: expected %v, got %v", tc.dep, tc.candidate.Name, tc.hostArch, tc.expected, actual)
+		}
+	}
+}
+
+func TestDependencyConflictsWith(t *testing.T) {
+	amd64, i386 := MakeArch("amd64"), MakeArch("i386")
+
+	gnuMake := Candidate{Name: "make", Version: MakeVersion("4.3-4"), Architecture: amd64, MultiArch: MultiArchForeign}
+	libfoo := Candidate{Name: "libfoo", Version: MakeVersion("2.0-1"), Architecture: i386, MultiArch: MultiArchSame}
+	bar := Candidate{Name: "bar", Version: MakeVersion("1.0-1"), Architecture: i386}
+
+	testCases := []struct {
+		dep       string
+		candidate Candidate
+		expected  bool
+	}{
+		{"make:any", gnuMake, true}, // :any matches regardless of M-A: allowed
+		{"libfoo:any", libfoo, true},
+		{"bar:any (<< 2.0)", bar, true},
+		{"bar:any (>= 2.0)", bar, false},
+		{"bar", bar, false}, // unqualified conflict is limited to the same architecture
+		{"bar:i386", bar, true},
+	}
+
+	for _, tc := range testCases {
+		var dep Dependency
+		if err := dep.UnmarshalText([]byte(tc.dep)); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := dep.ConflictsWith(tc.candidate, amd64, amd64); actual != tc.expected {
+			t.Errorf("%s with %s: expected %v, got %v", tc.dep, tc.candidate.Name, tc.expected, actual)
+		}
+	}
+}
+
+func TestDependencyReduce(t *testing.T) {
+	var d Dependency
+	if err := d.UnmarshalText([]byte("foo [amd64] | bar [i386] | baz <!nocheck>")); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		arch     string
+		profiles []string
+		expected string
+	}{
+		{"amd64", nil, "foo|baz"},
+		{"i386", nil, "bar|baz"},
+		{"i386", []string{"nocheck"}, "bar"},
+		{"arm64", []string{"nocheck"}, ""},
+	}
+
+	for _, c := range cases {
+		reduced, ok := d.Reduce(MakeArch(c.arch), c.profiles)
+
+		var names []string
+		for alt := &reduced; ok && alt != nil; alt = alt.Alt {
+			names = append(names, alt.Name)
+		}
+
+		if ok != (c.expected != "") || strings.Join(names, "|") != c.expected {
+			t.Fatalf("%s %v: expected '%s', got %v (%v)", c.arch, c.profiles, c.expected, names, ok)
+		}
+	}
+}
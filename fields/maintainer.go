@@ -1,22 +1,33 @@
 package fields
 
 import (
+	"bytes"
 	"encoding"
 	"fmt"
 )
 
+// Maintainer, Uploaders or Changed-By entry in the RFC 822 form: `Full Name <email@example.net>`
 type Maintainer struct {
 	Name  string
 	Email string
 }
 
+/*
+UnmarshalText is lenient, as malformed entries do exist in the archive: without an email the whole text is the name,
+unterminated email runs to the end of the text.
+*/
 func (m *Maintainer) UnmarshalText(text []byte) (err error) {
-	// strings.Between()
-	return
+	name, rest, _ := bytes.Cut(bytes.TrimSpace(text), []byte{'<'})
+	email, _, _ := bytes.Cut(rest, []byte{'>'})
+
+	m.Name = string(bytes.TrimSpace(name))
+	m.Email = string(bytes.TrimSpace(email))
+
+	return nil
 }
 
 func (m *Maintainer) MarshalText() (text []byte, err error) {
-	return
+	return []byte(m.String()), nil
 }
 
 func (m Maintainer) String() string {
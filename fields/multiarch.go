@@ -2,19 +2,23 @@ package fields
 
 import (
 	"bytes"
+	"encoding"
 	"fmt"
 )
 
+// Multi-Arch field value. The zero value is [MultiArchNo], same as a missing field
 type MultiArch int
 
 const (
-	MultiArchSame MultiArch = iota
+	MultiArchNo MultiArch = iota
+	MultiArchSame
 	MultiArchForeign
 	MultiArchAllowed
 )
 
 var string2Type = map[string]MultiArch{
-	"":        MultiArchSame,
+	"":        MultiArchNo,
+	"no":      MultiArchNo,
 	"same":    MultiArchSame,
 	"foreign": MultiArchForeign,
 	"allowed": MultiArchAllowed,
@@ -30,6 +34,13 @@ func (ma *MultiArch) UnmarshalText(text []byte) (err error) {
 	return fmt.Errorf("wrong multiarch type '%s'", text)
 }
 
+func (ma *MultiArch) MarshalText() (text []byte, err error) {
+	return []byte(ma.String()), nil
+}
+
 func (ma MultiArch) String() string {
-	return [...]string{"same", "foreign", "allowed"}[ma]
+	return [...]string{"no", "same", "foreign", "allowed"}[ma]
 }
+
+var _ encoding.TextMarshaler = (*MultiArch)(nil)
+var _ encoding.TextUnmarshaler = (*MultiArch)(nil)
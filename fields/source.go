@@ -0,0 +1,50 @@
+package fields
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+
+	"github.com/aol-nnov/debian/internal/stringspp"
+)
+
+/*
+Source field of a binary package: source package name, optionally followed by its version in parentheses, if it
+differs from the binary package version (i.e. for binNMUs):
+
+	Source: glibc (2.36-9+deb12u4)
+*/
+type Source struct {
+	Name    string
+	Version *Version // nil, if source version is the same as the binary one
+}
+
+func (s *Source) UnmarshalText(text []byte) (err error) {
+	name, rest, _ := bytes.Cut(bytes.TrimSpace(text), space)
+
+	s.Name = string(name)
+	s.Version = nil
+
+	if version, found, _ := stringspp.Between(rest, '(', ')', false); found {
+		s.Version = &Version{}
+		if err = s.Version.UnmarshalText(version[1 : len(version)-1]); err != nil {
+			return fmt.Errorf("Source: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Source) MarshalText() (text []byte, err error) {
+	return []byte(s.String()), nil
+}
+
+func (s Source) String() string {
+	if s.Version == nil {
+		return s.Name
+	}
+	return fmt.Sprintf("%s (%s)", s.Name, s.Version)
+}
+
+var _ encoding.TextMarshaler = (*Source)(nil)
+var _ encoding.TextUnmarshaler = (*Source)(nil)
@@ -0,0 +1,43 @@
+package fields_test
+
+import (
+	"fmt"
+
+	"github.com/aol-nnov/debian/fields"
+)
+
+func ExampleSource() {
+	var s fields.Source
+	s.UnmarshalText([]byte("glibc (2.36-9+deb12u4)"))
+	fmt.Println(s.Name, s.Version)
+
+	s.UnmarshalText([]byte("hello"))
+	fmt.Println(s.Name, s.Version == nil)
+
+	// Output:
+	// glibc 2.36-9+deb12u4
+	// hello true
+}
+
+func ExampleMaintainer() {
+	var m fields.Maintainer
+	m.UnmarshalText([]byte("Debian Games Team <pkg-games-devel@lists.alioth.debian.org>"))
+	fmt.Printf("%s|%s\n", m.Name, m.Email)
+
+	// Output: Debian Games Team|pkg-games-devel@lists.alioth.debian.org
+}
+
+func ExampleMaintainer_malformed() {
+	for _, text := range []string{"Debian Games Team", "John Doe <john@example.org", "<john@example.org>"} {
+		var m fields.Maintainer
+		if err := m.UnmarshalText([]byte(text)); err != nil {
+			fmt.Println(err)
+		}
+		fmt.Printf("%s|%s|%s\n", m.Name, m.Email, m)
+	}
+
+	// Output:
+	// Debian Games Team||Debian Games Team <>
+	// John Doe|john@example.org|John Doe <john@example.org>
+	// |john@example.org| <john@example.org>
+}
@@ -28,20 +28,25 @@ type VersionConstraint struct {
 	Value Version
 }
 
+// SatisfiedBy reports whether version another matches the constraint, i.e. 1.2-1 satisfies (>= 1.0)
 func (v *VersionConstraint) SatisfiedBy(another Version) bool {
 	if v.Op == VersionConstraintNotSet {
 		return true
 	}
 
-	cmpRes := v.Value.Compare(another)
+	cmpRes := another.Compare(v.Value)
 
 	switch v.Op {
-	case VersionConstraintGreaterThan, VersionConstraintGreaterOrEqual:
+	case VersionConstraintGreaterThan:
+		return cmpRes == VersionCompareResultGreaterThan
+	case VersionConstraintGreaterOrEqual:
 		return cmpRes == VersionCompareResultGreaterThan || cmpRes == VersionCompareResultEquals
 	case VersionConstraintEqual:
 		return cmpRes == VersionCompareResultEquals
-	case VersionConstraintLessThan, VersionConstraintLessOrEqual:
+	case VersionConstraintLessOrEqual:
 		return cmpRes == VersionCompareResultLessThan || cmpRes == VersionCompareResultEquals
+	case VersionConstraintLessThan:
+		return cmpRes == VersionCompareResultLessThan
 	}
 
 	return false
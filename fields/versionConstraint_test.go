@@ -28,3 +28,35 @@ func TestUnmarshalVersionConstraint(t *testing.T) {
 
 	t.Log(vc)
 }
+
+func TestVersionConstraintSatisfiedBy(t *testing.T) {
+	testCases := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{"(>= 1.2-1)", "1.2-1", true},
+		{"(>= 1.2-1)", "1.3-1", true},
+		{"(>= 1.2-1)", "1.1-1", false},
+		{"(>> 1.2-1)", "1.2-1", false},
+		{"(>> 1.2-1)", "1.2-1+b1", true},
+		{"(= 1.2-1)", "1.2-1", true},
+		{"(= 1.2-1)", "1.2-2", false},
+		{"(<= 1.2-1)", "1.2-1", true},
+		{"(<= 1.2-1)", "1.2-1~bpo1", true},
+		{"(<< 1.2-1)", "1.2-1", false},
+		{"(<< 1.2-1)", "1.1", true},
+		{"(<< 1:0.1)", "2.0", true},
+	}
+
+	for _, tc := range testCases {
+		var vc fields.VersionConstraint
+		if err := vc.UnmarshalText([]byte(tc.constraint)); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := vc.SatisfiedBy(fields.MakeVersion(tc.version)); actual != tc.expected {
+			t.Errorf("%s %s: expected %v, got %v", tc.version, tc.constraint, tc.expected, actual)
+		}
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/aol-nnov/debian/fields"
 )
 
-func ExampleQuiltBump() {
+func ExampleVersion_Bump_quilt() {
 	v := fields.MakeVersion("1.2.3-1.1~1.gbpasd")
 	fmt.Println(v.Modificators)
 	fmt.Println(v.DebianRevision)
@@ -22,7 +22,7 @@ func ExampleQuiltBump() {
 	// 1.2.3-2~1.gbpbooo
 }
 
-func ExampleNativeBump() {
+func ExampleVersion_Bump_native() {
 	v := fields.MakeVersion("1.2.3+b5~1.gbpasd")
 
 	v.Bump(fields.ChangeImpactTrivial)
@@ -31,7 +31,7 @@ func ExampleNativeBump() {
 	// Output: 1.2.4
 }
 
-func ExampleSnapshotBinNmu() {
+func ExampleVersion_BinaryNmu_snapshot() {
 
 	v := fields.MakeVersion("3:1.2.3")
 	fmt.Println(v)
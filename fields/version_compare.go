@@ -1,5 +1,7 @@
 package fields
 
+import "strings"
+
 func (v Version) Less(another Version) bool {
 	return v.Compare(another) == VersionCompareResultLessThan
 }
@@ -35,9 +37,26 @@ func (v Version) Compare(another Version) VersionCompareResult {
 		return VersionCompareResultNonComparable
 	}
 
-	if v.Epoch > another.Epoch {
-		return VersionCompareResultGreaterThan
+	if v.Epoch != another.Epoch {
+		return intToVersionCompareResult(v.Epoch - another.Epoch)
+	}
+
+	vUpstream, vRevision := v.parts()
+	anotherUpstream, anotherRevision := another.parts()
+
+	if res := verrevcmp(vUpstream, anotherUpstream); res != VersionCompareResultEquals {
+		return res
 	}
 
-	return verrevcmp(v.String(), another.String())
+	return verrevcmp(vRevision, anotherRevision)
+}
+
+// parts returns upstream version and debian revision with modificators
+// attached to the part they were extracted from
+func (v Version) parts() (upstream, revision string) {
+	mods := strings.Join(v.Modificators, "")
+	if v.DebianRevision == "" {
+		return v.UpstreamVersion + mods, ""
+	}
+	return v.UpstreamVersion, v.DebianRevision + mods
 }
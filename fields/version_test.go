@@ -50,6 +50,29 @@ func TestCompare(t *testing.T) {
 	}
 }
 
+func TestCompareParts(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want fields.VersionCompareResult
+	}{
+		{"1.0-1", "1.0+b1-1", fields.VersionCompareResultLessThan},
+		{"1.0+dfsg-1", "1.0-1", fields.VersionCompareResultGreaterThan},
+		{"1.0+dfsg-1", "1.0-2", fields.VersionCompareResultGreaterThan},
+		{"1.0~rc1-1", "1.0-1", fields.VersionCompareResultLessThan},
+		{"1.0~rc1-2", "1.0-1", fields.VersionCompareResultLessThan},
+		{"1.0", "1.0-1", fields.VersionCompareResultLessThan},
+		{"1.0", "1.0-0", fields.VersionCompareResultEquals},
+		{"1.0.1", "1.0-9", fields.VersionCompareResultGreaterThan},
+		{"1.0-1+b1", "1.0-1", fields.VersionCompareResultGreaterThan},
+		{"1.0-1.1", "1.0-1", fields.VersionCompareResultGreaterThan},
+	} {
+		a, b := fields.MakeVersion(tc.a), fields.MakeVersion(tc.b)
+		if got := a.Compare(b); got != tc.want {
+			t.Errorf("%s %v %s, want %v", tc.a, got, tc.b, tc.want)
+		}
+	}
+}
+
 func TestMakeSnapshot(t *testing.T) {
 	v := fields.MakeVersion("1.2.3")
 	v.Snapshot("deadbeef")
@@ -188,3 +211,14 @@ func ExampleVersion_Bump_threeDigit() {
 	// Output:
 	// 2.10.0
 }
+
+func TestLessEpoch(t *testing.T) {
+	v1 := fields.MakeVersion("2.0-1")
+	v2 := fields.MakeVersion("1:0.5-1")
+
+	if !v1.Less(v2) || v2.Less(v1) {
+		t.Fail()
+	}
+
+	t.Logf("%v %v %v", v1, v1.Compare(v2), v2)
+}
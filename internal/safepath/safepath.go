@@ -0,0 +1,56 @@
+// Package safepath resolves untrusted relative paths (from archives or patches) inside a root directory
+package safepath
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutside is returned for paths, which lead outside of the root directory
+var ErrOutside = errors.New("path leads outside of the target directory")
+
+/*
+Join returns root joined with name, which must be a relative slash separated path. Paths containing ".." components,
+which escape root, absolute paths and paths going through symbolic links existing under root are rejected with
+[ErrOutside], so writing to the result never touches anything outside of root.
+
+The last component may be a symbolic link itself, it is not followed.
+*/
+func Join(root, name string) (string, error) {
+	if path.IsAbs(name) || filepath.IsAbs(name) {
+		return "", fmt.Errorf("%s: %w", name, ErrOutside)
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%s: %w", name, ErrOutside)
+	}
+	if cleaned == "." {
+		return root, nil
+	}
+
+	// parent directories must not be symbolic links
+	parts := strings.Split(cleaned, "/")
+	current := root
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%s: %w through a symbolic link", name, ErrOutside)
+		}
+	}
+
+	return filepath.Join(root, filepath.FromSlash(cleaned)), nil
+}
@@ -4,57 +4,58 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net/http"
-	"os"
-	"strings"
 
+	"github.com/aol-nnov/debian/fetch"
 	"github.com/mholt/archives"
 )
 
+// New opens src (http(s) url or a local file) with [fetch.Default] and decompresses it on the fly, if it is compressed
 func New(src string) (io.Reader, error) {
-
-	var srcReader io.Reader
-	var err error
-
-	if strings.HasPrefix(src, "http") {
-		client := http.DefaultClient
-		var req *http.Request
-
-		if req, err = http.NewRequest("GET", src, nil); err != nil {
-			return nil, err
-		}
-
-		if authUser := os.Getenv("HTTP_USER"); authUser != "" {
-			req.SetBasicAuth(authUser, os.Getenv("HTTP_PASSWD"))
-		}
-
-		if res, err := client.Do(req); err != nil {
-			return nil, err
-		} else {
-			if res.StatusCode == 200 {
-				srcReader = res.Body
-			} else {
-				return nil, fmt.Errorf("UniversalReader: %s", res.Status)
-			}
-		}
-	} else {
-		if srcReader, err = os.Open(src); err != nil {
-			return nil, err
-		}
+	srcReader, err := fetch.Default().Fetch(context.Background(), src)
+	if err != nil {
+		return nil, err
 	}
 
+	return Decompress(srcReader)
+}
+
+// Decompress detects compression format of srcReader and returns decompressing reader. Uncompressed data is passed
+// through. Closing the result closes srcReader as well
+func Decompress(srcReader io.Reader) (io.Reader, error) {
 	if format, input, err := archives.Identify(context.TODO(), "", srcReader); err != nil {
 		// format not detected (i.e. file is not compressed)
-		return input, nil
+		return readCloser{Reader: input, src: srcReader}, nil
 	} else {
 		// fmt.Printf("%s %v\n", format.Name(), input)
 		if decompressor, ok := format.(archives.Decompressor); ok {
-			return decompressor.OpenReader(input)
+			decompressed, err := decompressor.OpenReader(input)
+			if err != nil {
+				return nil, err
+			}
+			return readCloser{Reader: decompressed, decompressor: decompressed, src: srcReader}, nil
 		}
 	}
 	return nil, fmt.Errorf("NewUniversalReader: stranger things")
 }
 
+// closes both decompressor and the source
+type readCloser struct {
+	io.Reader
+	decompressor io.Reader // nil for uncompressed data
+	src          io.Reader
+}
+
+func (rc readCloser) Close() error {
+	var err error
+	if rc.decompressor != nil {
+		err = MaybeClose(rc.decompressor)
+	}
+	if srcErr := MaybeClose(rc.src); err == nil {
+		err = srcErr
+	}
+	return err
+}
+
 func MaybeClose(reader io.Reader) error {
 	if closer, ok := reader.(io.Closer); ok {
 		return closer.Close()
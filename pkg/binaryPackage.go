@@ -5,27 +5,52 @@ import "github.com/aol-nnov/debian/fields"
 // represents binary package stanaza
 // https://www.debian.org/doc/debian-policy/ch-controlfields.html
 type BinaryPackage struct {
-	Name         string              `deb822:"Package" required:"true"`
-	Architecture fields.Architecture `required:"true"`
-	Section      string              `deb822:",omitempty" recommended:"true"`
-	Priority     string              `deb822:",omitempty" recommended:"true"`
-	Essential    string
+	Name          string              `deb822:"Package" required:"true"`
+	Source        string              `deb822:",omitempty"` // present in DEBIAN/control only
+	Version       fields.Version      `deb822:",omitempty"` // present in DEBIAN/control only
+	Architecture  fields.Architecture `required:"true"`
+	Maintainer    string              `deb822:",omitempty"`               // present in DEBIAN/control only
+	InstalledSize int                 `deb822:"Installed-Size,omitempty"` // in KiB, present in DEBIAN/control only
+	Essential     string              `deb822:",omitempty"`
 
 	Depends    fields.Dependencies `deb822:",omitempty" delim:","` //
 	Recommends fields.Dependencies `deb822:",omitempty" delim:","`
 	Suggests   fields.Dependencies `deb822:",omitempty" delim:","`
 	Enhances   fields.Dependencies `deb822:",omitempty" delim:","`
 	PreDepends fields.Dependencies `deb822:"Pre-Depends,omitempty" delim:","`
+	Conflicts  fields.Dependencies `deb822:",omitempty" delim:","`
+	Breaks     fields.Dependencies `deb822:",omitempty" delim:","`
+	Replaces   fields.Dependencies `deb822:",omitempty" delim:","`
+	Provides   fields.Dependencies `deb822:",omitempty" delim:"," strip:" \n"`
 
-	Description string `required:"true"`
-	Homepage    string `deb822:",omitempty"`
+	Section   string           `deb822:",omitempty" recommended:"true"`
+	Priority  string           `deb822:",omitempty" recommended:"true"`
+	MultiArch fields.MultiArch `deb822:"Multi-Arch,omitempty"`
+	Homepage  string           `deb822:",omitempty"`
 
-	Provides []string `deb822:",omitempty" delim:"," strip:" "`
+	// fields not listed above, i.e. Built-Using or Static-Built-Using
+	Extra map[string]string `deb822:",extra"`
 
-	MultiArch fields.MultiArch `deb822:"Multi-Arch,omitempty"`
+	Description string `required:"true"`
+}
+
+// Candidate returns package properties relevant for dependency resolution
+func (pkg BinaryPackage) Candidate() fields.Candidate {
+	return fields.Candidate{
+		Name:         pkg.Name,
+		Version:      pkg.Version,
+		Architecture: pkg.Architecture,
+		MultiArch:    pkg.MultiArch,
+		Provides:     pkg.Provides,
+	}
 }
 
+/*
+Satisfies reports whether the package satisfies one of the alternatives of dep, which apply to the build (see
+[fields.Dependency.Reduce])
+*/
 func (pkg BinaryPackage) Satisfies(dep fields.Dependency, buildArch fields.Architecture,
 	hostArch fields.Architecture, profiles []string) bool {
-	return false
+	reduced, ok := dep.Reduce(hostArch, profiles)
+	return ok && reduced.SatisfiedBy(pkg.Candidate(), buildArch, hostArch)
 }
@@ -0,0 +1,53 @@
+package pkg_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/pkg"
+)
+
+func ExampleBinaryPackage_Satisfies() {
+	python := pkg.BinaryPackage{
+		Name:         "python3",
+		Version:      fields.MakeVersion("3.11.2-1"),
+		Architecture: fields.MakeArch("amd64"),
+		MultiArch:    fields.MultiArchAllowed,
+	}
+
+	amd64, arm64 := fields.MakeArch("amd64"), fields.MakeArch("arm64")
+
+	for _, s := range []string{"python3:any (>= 3.11)", "python3 (>= 3.11)", "python3:any <!nopython>"} {
+		var dep fields.Dependency
+		dep.UnmarshalText([]byte(s))
+
+		fmt.Println(python.Satisfies(dep, amd64, arm64, []string{"nopython"}))
+	}
+
+	// Output:
+	// true
+	// false
+	// false
+}
+
+func TestSatisfiesRestrictedAlternative(t *testing.T) {
+	bar := pkg.BinaryPackage{Name: "bar", Version: fields.MakeVersion("1.0"), Architecture: fields.MakeArch("amd64")}
+	amd64 := fields.MakeArch("amd64")
+
+	var dep fields.Dependency
+	dep.UnmarshalText([]byte("foo [i386] | bar"))
+
+	if !dep.Satisfies(amd64, amd64, nil) {
+		t.Fatal("unrestricted alternative applies to the build")
+	}
+	if !bar.Satisfies(dep, amd64, amd64, nil) {
+		t.Fatal("bar satisfies foo [i386] | bar on amd64")
+	}
+
+	dep = fields.Dependency{}
+	dep.UnmarshalText([]byte("foo | bar [i386]"))
+	if bar.Satisfies(dep, amd64, amd64, nil) {
+		t.Fatal("bar [i386] does not apply on amd64")
+	}
+}
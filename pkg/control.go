@@ -10,6 +10,7 @@ import (
 
 type binaryPackageInSrc struct {
 	Name         string              `deb822:"Package" required:"true"`
+	PackageType  string              `deb822:"Package-Type"` // deb if empty, or udeb
 	Architecture fields.Architecture `required:"true"`
 	Section      string              `recommended:"true"`
 	Priority     string              `recommended:"true"`
@@ -9,9 +9,21 @@ type SourcePackage struct {
 	Section          string             `deb822:",omitempty" recommended:"true"`
 	Priority         string             `deb822:",omitempty" recommended:"true"`
 	StandardsVersion fields.Version     `deb822:"Standards-Version" required:"true"`
-	Description      fields.Description `required:"true"`
+	Uploaders        string             `deb822:",omitempty"`
+	Homepage         string             `deb822:",omitempty"`
+	VcsBrowser       string             `deb822:"Vcs-Browser,omitempty"`
+	VcsGit           string             `deb822:"Vcs-Git,omitempty"`
+	Testsuite        string             `deb822:",omitempty"`
+	Description      fields.Description `deb822:",omitempty"`
 
 	BuildDepends      fields.Dependencies `deb822:"Build-Depends,omitempty" delim:"," strip:" "`
 	BuildDependsArch  fields.Dependencies `deb822:"Build-Depends-Arch,omitempty" delim:"," strip:" "`
 	BuildDependsIndep fields.Dependencies `deb822:"Build-Depends-Indep,omitempty" delim:"," strip:" "`
+
+	BuildConflicts      fields.Dependencies `deb822:"Build-Conflicts,omitempty" delim:"," strip:" "`
+	BuildConflictsArch  fields.Dependencies `deb822:"Build-Conflicts-Arch,omitempty" delim:"," strip:" "`
+	BuildConflictsIndep fields.Dependencies `deb822:"Build-Conflicts-Indep,omitempty" delim:"," strip:" "`
+
+	// fields not listed above, i.e. Vcs-Svn or Rules-Requires-Root
+	Extra map[string]string `deb822:",extra"`
 }
@@ -0,0 +1,276 @@
+package quilt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aol-nnov/debian/internal/safepath"
+)
+
+// SeriesPath is the location of the series file of a "3.0 (quilt)" source package
+const SeriesPath = "debian/patches/series"
+
+/*
+ApplySeries applies patches listed in debian/patches/series of an unpacked source tree in order. Missing series file
+means there is nothing to apply.
+*/
+func ApplySeries(dir string) error {
+	seriesFile, err := os.Open(filepath.Join(dir, filepath.FromSlash(SeriesPath)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer seriesFile.Close()
+
+	series, err := ReadSeries(seriesFile)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range series {
+		if err := applyFile(dir, entry); err != nil {
+			return fmt.Errorf("patch %s: %w", entry.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyFile(dir string, entry SeriesEntry) error {
+	name, err := safepath.Join(dir, path.Join(path.Dir(SeriesPath), entry.Name))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	patch, err := ParsePatch(file)
+	if err != nil {
+		return err
+	}
+
+	return Apply(dir, patch, entry.Strip)
+}
+
+// ApplyOptions control how patches are applied
+type ApplyOptions struct {
+	Strip  int  // number of leading path components to strip from file names, like patch -pN does
+	Fuzz   int  // maximum number of context lines ignored at either end of a hunk, like patch -F does
+	DryRun bool // only check that the patch applies, do not change anything
+}
+
+// HunkResult tells how a hunk was applied
+type HunkResult struct {
+	File   string // file name with leading components stripped
+	Hunk   int    // hunk number within the file, starting with 1
+	Line   int    // line the hunk was applied at, starting with 1
+	Offset int    // lines between the stated and the actual position of the hunk
+	Fuzz   int    // context lines ignored at either end of the hunk
+}
+
+func (r HunkResult) String() string {
+	res := fmt.Sprintf("%s: hunk #%d succeeded at %d", r.File, r.Hunk, r.Line)
+	if r.Fuzz > 0 {
+		res += fmt.Sprintf(" with fuzz %d", r.Fuzz)
+	}
+	if r.Offset != 0 {
+		res += fmt.Sprintf(" (offset %d lines)", r.Offset)
+	}
+	return res
+}
+
+// HunkError is a hunk, which context was not found
+type HunkError struct {
+	File string
+	Hunk int // hunk number within the file, starting with 1
+	Line int // stated line of the hunk
+}
+
+func (e *HunkError) Error() string {
+	return fmt.Sprintf("%s: hunk #%d FAILED at %d", e.File, e.Hunk, e.Line)
+}
+
+/*
+Apply applies the patch to the tree at dir, stripping strip leading components from file names, like patch -pN does.
+Hunk context must match exactly, see [ApplyWithOptions].
+*/
+func Apply(dir string, p *Patch, strip int) error {
+	_, err := ApplyWithOptions(dir, p, ApplyOptions{Strip: strip})
+	return err
+}
+
+/*
+ApplyWithOptions applies the patch to the tree at dir and reports where each hunk was applied.
+
+Hunks are looked for around their stated position, adjusted by lines added or removed by preceding hunks. With
+non-zero Fuzz, up to that many context lines are ignored at either end of a hunk, if it does not apply as is. Each
+file is written only if all of its hunks apply, but files changed before the failing one are left changed, the
+failure is reported as [*HunkError]. Names leading outside of dir or through symbolic links are rejected.
+*/
+func ApplyWithOptions(dir string, p *Patch, opts ApplyOptions) ([]HunkResult, error) {
+	var res []HunkResult
+
+	for _, file := range p.Files {
+		results, err := applyDiff(dir, file, opts)
+		res = append(res, results...)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+func applyDiff(dir string, diff FileDiff, opts ApplyOptions) ([]HunkResult, error) {
+	created, deleted := diff.OldName == DevNull, diff.NewName == DevNull
+
+	name := diff.NewName
+	if deleted {
+		name = diff.OldName
+	}
+
+	name, err := stripComponents(name, opts.Strip)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := safepath.Join(dir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := fs.FileMode(0o644)
+	var lines []string
+	eol := true
+
+	info, err := os.Lstat(target)
+	switch {
+	case err == nil && !info.Mode().IsRegular():
+		return nil, fmt.Errorf("%s: not a regular file", name)
+	case err == nil && created && info.Size() > 0:
+		return nil, fmt.Errorf("%s: file to be created already exists", name)
+	case err == nil:
+		mode = info.Mode().Perm()
+
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return nil, err
+		}
+		lines, eol = splitLines(string(data))
+	case !errors.Is(err, fs.ErrNotExist) || !created:
+		return nil, err
+	}
+
+	var res []HunkResult
+	delta, lastOffset := 0, 0
+	for idx, hunk := range diff.Hunks {
+		// zero length side refers to the line preceding the change
+		expected := hunk.OldStart - 1
+		if hunk.OldLines == 0 {
+			expected = hunk.OldStart
+		}
+		expected += delta
+
+		found := false
+		for fuzz := 0; fuzz <= opts.Fuzz && !found; fuzz++ {
+			old, new, skipped := hunk.trim(fuzz)
+
+			var pos int
+			if pos, found = locate(lines, old, expected+lastOffset+skipped); !found {
+				continue
+			}
+
+			if pos+len(old) == len(lines) {
+				eol = !hunk.NewNoNewline
+			}
+
+			lines = slices.Replace(lines, pos, pos+len(old), new...)
+			delta += hunk.NewLines - hunk.OldLines
+			lastOffset = pos - skipped - expected
+
+			res = append(res, HunkResult{File: name, Hunk: idx + 1, Line: pos - skipped + 1, Offset: lastOffset, Fuzz: fuzz})
+		}
+
+		if !found {
+			return res, &HunkError{File: name, Hunk: idx + 1, Line: hunk.OldStart}
+		}
+	}
+
+	if opts.DryRun {
+		return res, nil
+	}
+
+	if deleted {
+		if len(lines) > 0 {
+			return res, fmt.Errorf("%s: file to be deleted is not empty after patching", name)
+		}
+		return res, os.Remove(target)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return res, err
+	}
+
+	return res, os.WriteFile(target, []byte(joinLines(lines, eol)), mode)
+}
+
+// locate finds lines matching want, closest to the expected position first
+func locate(lines, want []string, expected int) (int, bool) {
+	expected = max(0, min(expected, len(lines)-len(want)))
+
+	for offset := 0; expected-offset >= 0 || expected+offset+len(want) <= len(lines); offset++ {
+		for _, pos := range []int{expected - offset, expected + offset} {
+			if pos >= 0 && pos+len(want) <= len(lines) && slices.Equal(lines[pos:pos+len(want)], want) {
+				return pos, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func stripComponents(name string, strip int) (string, error) {
+	res := name
+	for range strip {
+		var found bool
+		if _, res, found = strings.Cut(res, "/"); !found {
+			return "", fmt.Errorf("%s: unable to strip %d leading components", name, strip)
+		}
+	}
+
+	return res, nil
+}
+
+// splitLines splits text to lines, eol tells whether the last line is terminated
+func splitLines(text string) (lines []string, eol bool) {
+	if text == "" {
+		return nil, true
+	}
+
+	eol = strings.HasSuffix(text, "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), eol
+}
+
+func joinLines(lines []string, eol bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	res := strings.Join(lines, "\n")
+	if eol {
+		res += "\n"
+	}
+	return res
+}
@@ -0,0 +1,117 @@
+package quilt_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/internal/safepath"
+	"github.com/aol-nnov/debian/quilt"
+)
+
+const patch = `Description: example change
+Author: John Doe <john@example.org>
+---
+--- a/file.txt
++++ b/file.txt
+@@ -2,3 +2,3 @@
+ two
+-three
++THREE
+ four
+@@ -8,2 +8,3 @@ context
+ eight
+ nine
++ten
+\ No newline at end of file
+--- /dev/null
++++ b/new/created.txt
+@@ -0,0 +1 @@
++created
+`
+
+func TestApply(t *testing.T) {
+	p, err := quilt.ParsePatch(strings.NewReader(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(p.Header, "Description: example change\n") || len(p.Files) != 2 || len(p.Files[0].Hunks) != 2 {
+		t.Fatalf("unexpected patch %+v", p)
+	}
+
+	dir := t.TempDir()
+	// extra line on top, hunks are found with offset
+	os.WriteFile(filepath.Join(dir, "file.txt"), []byte("zero\none\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\n"), 0600)
+
+	if err := quilt.Apply(dir, p, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "file.txt"))
+	if string(content) != "zero\none\ntwo\nTHREE\nfour\nfive\nsix\nseven\neight\nnine\nten" {
+		t.Fatalf("unexpected result %q", content)
+	}
+
+	content, _ = os.ReadFile(filepath.Join(dir, "new/created.txt"))
+	if string(content) != "created\n" {
+		t.Fatalf("unexpected result %q", content)
+	}
+
+	// context does not match anymore
+	if err := quilt.Apply(dir, p, 1); err == nil || !strings.Contains(err.Error(), "FAILED") {
+		t.Fatalf("expected failure, got %v", err)
+	}
+}
+
+func TestApplyOutside(t *testing.T) {
+	p, err := quilt.ParsePatch(strings.NewReader("--- a/../escaped\n+++ b/../escaped\n@@ -0,0 +1 @@\n+evil\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := quilt.Apply(t.TempDir(), p, 1); !errors.Is(err, safepath.ErrOutside) {
+		t.Fatalf("expected %v, got %v", safepath.ErrOutside, err)
+	}
+}
+
+func TestApplyFuzz(t *testing.T) {
+	p, err := quilt.ParsePatch(strings.NewReader(`--- a/file.txt
++++ b/file.txt
+@@ -1,5 +1,5 @@
+ one
+ two
+-three
++THREE
+ four
+ five
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	// leading context changed and two lines added on top
+	os.WriteFile(filepath.Join(dir, "file.txt"), []byte("minus one\nzero\nONE\ntwo\nthree\nfour\nfive\n"), 0600)
+
+	_, err = quilt.ApplyWithOptions(dir, p, quilt.ApplyOptions{Strip: 1})
+	var hunkErr *quilt.HunkError
+	if !errors.As(err, &hunkErr) || hunkErr.Hunk != 1 {
+		t.Fatalf("expected hunk failure, got %v", err)
+	}
+
+	results, err := quilt.ApplyWithOptions(dir, p, quilt.ApplyOptions{Strip: 1, Fuzz: 2, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].String() != "file.txt: hunk #1 succeeded at 3 with fuzz 1 (offset 2 lines)" {
+		t.Fatalf("unexpected results %v", results)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "file.txt"))
+	if strings.Contains(string(content), "THREE") {
+		t.Fatal("dry run changed the file")
+	}
+}
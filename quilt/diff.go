@@ -0,0 +1,324 @@
+package quilt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aol-nnov/debian/internal/safepath"
+)
+
+// DiffContext is the number of context lines around changes in generated diffs
+const DiffContext = 3
+
+// longer edit scripts are not searched for, the differing part is replaced as a whole instead
+const maxEditDistance = 2000
+
+// marks the last line of a file without a trailing newline, so it differs from the same line terminated
+const noNewline = "\x00"
+
+// edit is a step of an edit script, old and new are positions in the respective sides before the step
+type edit struct {
+	kind     byte // ' ', '-' or '+'
+	old, new int
+}
+
+/*
+Diff compares the named files (slash separated paths relative to the trees) in orig and dir and returns unified diff
+of the ones differing. Files missing in orig are created by the patch, the ones missing in dir are deleted. File
+names are written with a/ and b/ prefixes, so the result applies with -p1.
+*/
+func Diff(orig, dir string, names ...string) (*Patch, error) {
+	var res Patch
+
+	for _, name := range names {
+		old, oldFound, err := readText(orig, name)
+		if err != nil {
+			return nil, err
+		}
+
+		new, newFound, err := readText(dir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if old == new && oldFound == newFound {
+			continue
+		}
+
+		file := FileDiff{OldName: "a/" + name, NewName: "b/" + name}
+		if !oldFound {
+			file.OldName = DevNull
+		}
+		if !newFound {
+			file.NewName = DevNull
+		}
+
+		file.Hunks = diffHunks(textLines(old), textLines(new), DiffContext)
+		res.Files = append(res.Files, file)
+	}
+
+	return &res, nil
+}
+
+/*
+Refresh regenerates the patch from the tree it applies to (orig) and the tree with the patch applied and, possibly,
+edited further (dir). Files touched by the patch and extra ones are compared, the ones without changes are dropped.
+Strip tells how to read file names of p, the header is kept as is. See [Diff] for the naming of files.
+*/
+func Refresh(orig, dir string, p *Patch, strip int, extra ...string) (*Patch, error) {
+	var names []string
+
+	for _, file := range p.Files {
+		name := file.NewName
+		if name == DevNull {
+			name = file.OldName
+		}
+
+		name, err := stripComponents(name, strip)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range extra {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	res, err := Diff(orig, dir, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Header = p.Header
+	return res, nil
+}
+
+// readText reads text file under root, missing file is reported with found set to false
+func readText(root, name string) (text string, found bool, err error) {
+	target, err := safepath.Join(root, name)
+	if err != nil {
+		return "", false, err
+	}
+
+	info, err := os.Lstat(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if !info.Mode().IsRegular() {
+		return "", false, fmt.Errorf("%s: not a regular file", name)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return "", false, err
+	}
+	if bytes.IndexByte(data, 0) != -1 {
+		return "", false, fmt.Errorf("%s: binary files are not supported", name)
+	}
+
+	return string(data), true, nil
+}
+
+// textLines splits text to lines, marking the unterminated last line
+func textLines(text string) []string {
+	lines, eol := splitLines(text)
+	if !eol {
+		lines[len(lines)-1] += noNewline
+	}
+	return lines
+}
+
+// diffHunks groups the changes between a and b into hunks with context lines around
+func diffHunks(a, b []string, context int) []Hunk {
+	edits := diffLines(a, b)
+
+	var res []Hunk
+	for idx := 0; idx < len(edits); {
+		if edits[idx].kind == ' ' {
+			idx++
+			continue
+		}
+
+		// changes separated by less than twice the context go to the same hunk
+		last := idx
+		for next := idx; next < len(edits) && next-last <= 2*context; next++ {
+			if edits[next].kind != ' ' {
+				last = next
+			}
+		}
+
+		start, stop := max(idx-context, 0), min(last+context+1, len(edits))
+		res = append(res, makeHunk(a, b, edits[start:stop]))
+		idx = stop
+	}
+
+	return res
+}
+
+func makeHunk(a, b []string, edits []edit) Hunk {
+	h := Hunk{OldStart: edits[0].old, NewStart: edits[0].new}
+
+	for _, e := range edits {
+		var line string
+		switch e.kind {
+		case ' ', '-':
+			line = a[e.old]
+			h.OldLines++
+		case '+':
+			line = b[e.new]
+		}
+		if e.kind != '-' {
+			h.NewLines++
+		}
+
+		if text, found := strings.CutSuffix(line, noNewline); found {
+			line = text
+			h.OldNoNewline = h.OldNoNewline || e.kind != '+'
+			h.NewNoNewline = h.NewNoNewline || e.kind != '-'
+		}
+
+		h.Lines = append(h.Lines, string(e.kind)+line)
+	}
+
+	// zero length side refers to the line preceding the change
+	if h.OldLines > 0 {
+		h.OldStart++
+	}
+	if h.NewLines > 0 {
+		h.NewStart++
+	}
+
+	return h
+}
+
+// diffLines returns the shortest edit script turning a into b, as found by Myers' algorithm
+func diffLines(a, b []string) []edit {
+	var res []edit
+
+	// common prefix and suffix are not worth searching through
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		res = append(res, edit{' ', prefix, prefix})
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	res = append(res, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix)...)
+
+	for idx := range suffix {
+		res = append(res, edit{' ', len(a) - suffix + idx, len(b) - suffix + idx})
+	}
+
+	return res
+}
+
+// myers returns edit script for a and b, which positions start at offset
+func myers(a, b []string, offset int) []edit {
+	n, m := len(a), len(b)
+
+	// furthest reaching x on each diagonal k = x - y, for every edit distance d
+	var trace [][]int
+	v := make([]int, 2*maxEditDistance+3)
+	center := maxEditDistance + 1
+
+	found := false
+	for d := 0; d <= min(n+m, maxEditDistance) && !found; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[center+k-1] < v[center+k+1] {
+				x = v[center+k+1] // down, insertion
+			} else {
+				x = v[center+k-1] + 1 // right, deletion
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[center+k] = x
+
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		trace = append(trace, slices.Clone(v[center-d:center+d+1]))
+	}
+
+	if !found {
+		// too different, replace as a whole
+		var res []edit
+		for idx := range n {
+			res = append(res, edit{'-', offset + idx, offset})
+		}
+		for idx := range m {
+			res = append(res, edit{'+', offset + n, offset + idx})
+		}
+		return res
+	}
+
+	// walk back from the end, collecting edits in reverse order
+	var res []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+
+		var prevK int
+		if d > 0 {
+			prev := func(k int) int { return trace[d-1][k+d-1] }
+			if k == -d || k != d && prev(k-1) < prev(k+1) {
+				prevK = k + 1
+			} else {
+				prevK = k - 1
+			}
+
+			prevX := prev(prevK)
+			prevY := prevX - prevK
+
+			// diagonal moves following the edit
+			for x > prevX && y > prevY && (prevK == k+1 && x > prevX || prevK == k-1 && x > prevX+1) {
+				x--
+				y--
+				res = append(res, edit{' ', offset + x, offset + y})
+			}
+
+			if prevK == k+1 {
+				y--
+				res = append(res, edit{'+', offset + x, offset + y})
+			} else {
+				x--
+				res = append(res, edit{'-', offset + x, offset + y})
+			}
+			continue
+		}
+
+		// leading diagonal
+		for x > 0 {
+			x--
+			y--
+			res = append(res, edit{' ', offset + x, offset + y})
+		}
+	}
+
+	slices.Reverse(res)
+	return res
+}
@@ -0,0 +1,103 @@
+package quilt_test
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/quilt"
+)
+
+func ExampleRefresh() {
+	orig, _ := os.MkdirTemp("", "orig")
+	defer os.RemoveAll(orig)
+	dir, _ := os.MkdirTemp("", "tree")
+	defer os.RemoveAll(dir)
+
+	os.WriteFile(filepath.Join(orig, "greeting.txt"), []byte("hello\nworld\n"), 0644)
+
+	p, _ := quilt.ParsePatch(strings.NewReader(`Description: greet everyone
+---
+--- a/greeting.txt
++++ b/greeting.txt
+@@ -1,2 +1,2 @@
+ hello
+-world
++universe
+`))
+
+	// the patch is applied and the result is edited further
+	os.WriteFile(filepath.Join(dir, "greeting.txt"), []byte("hello\nmultiverse\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "NEWS"), []byte("greetings changed\n"), 0644)
+
+	refreshed, err := quilt.Refresh(orig, dir, p, 1, "NEWS")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	refreshed.WriteTo(os.Stdout)
+
+	// Output:
+	// Description: greet everyone
+	// ---
+	// --- a/greeting.txt
+	// +++ b/greeting.txt
+	// @@ -1,2 +1,2 @@
+	//  hello
+	// -world
+	// +multiverse
+	// --- /dev/null
+	// +++ b/NEWS
+	// @@ -0,0 +1 @@
+	// +greetings changed
+}
+
+// random text of a few distinct lines, so that there are common parts
+func randomText(r *rand.Rand) string {
+	var lines []string
+	for range r.Intn(30) {
+		lines = append(lines, string(rune('a'+r.Intn(4))))
+	}
+
+	text := strings.Join(lines, "\n")
+	if len(lines) > 0 && r.Intn(3) > 0 {
+		text += "\n"
+	}
+	return text
+}
+
+func TestDiffApply(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for range 200 {
+		orig, dir := t.TempDir(), t.TempDir()
+		a, b := randomText(r), randomText(r)
+		os.WriteFile(filepath.Join(orig, "file"), []byte(a), 0644)
+		os.WriteFile(filepath.Join(dir, "file"), []byte(b), 0644)
+
+		p, err := quilt.Diff(orig, dir, "file")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var text strings.Builder
+		p.WriteTo(&text)
+
+		parsed, err := quilt.ParsePatch(strings.NewReader(text.String()))
+		if err != nil {
+			t.Fatalf("%v\n%s", err, text.String())
+		}
+
+		if err := quilt.Apply(orig, parsed, 1); err != nil {
+			t.Fatalf("%v\n%s", err, text.String())
+		}
+
+		if got, _ := os.ReadFile(filepath.Join(orig, "file")); string(got) != b {
+			t.Fatalf("expected %q, got %q\n%s", b, got, text.String())
+		}
+	}
+}
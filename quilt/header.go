@@ -0,0 +1,152 @@
+package quilt
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aol-nnov/debian/deb822"
+)
+
+/*
+Header is a DEP-3 patch header. Vendor specific bug references (i.e. Bug-Debian, Bug-Ubuntu) and other fields not
+listed here end up in Extra.
+
+https://dep-team.pages.debian.net/deps/dep3/
+*/
+type Header struct {
+	Description     string `deb822:",omitempty"` // Subject is accepted as well
+	Author          string `deb822:",omitempty"` // From is accepted as well
+	Origin          string `deb822:",omitempty"`
+	Bug             string `deb822:",omitempty"`
+	Forwarded       string `deb822:",omitempty"`
+	ReviewedBy      string `deb822:"Reviewed-By,omitempty"`
+	AppliedUpstream string `deb822:"Applied-Upstream,omitempty"`
+	LastUpdate      string `deb822:"Last-Update,omitempty"` // YYYY-MM-DD
+
+	Extra map[string]string `deb822:",extra"`
+}
+
+var (
+	fieldLine     = regexp.MustCompile(`^[^\s:#]+:`)
+	gitMailHeader = regexp.MustCompile(`^From [0-9a-f]{40} `)
+	subjectTag    = regexp.MustCompile(`^\[PATCH[^\]]*\]\s*`)
+)
+
+// aliases of the DEP-3 fields, used by git format-patch
+var headerAliases = map[string]string{"subject": "Description", "from": "Author"}
+
+/*
+ParseHeader parses DEP-3 header, i.e. [Patch.Header]. Parsing stops at the "---" separator or at the start of a diff.
+
+Paragraphs consisting of fields are merged, free-form paragraphs are appended to the Description as its long part, as
+DEP-3 allows. Paragraphs of the long description are separated with empty lines. Headers produced by git format-patch
+are understood as well.
+*/
+func ParseHeader(text string) (*Header, error) {
+	var fieldParagraphs, freeForm []string
+
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+
+		isFields := true
+		for _, line := range paragraph {
+			if !fieldLine.MatchString(line) && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+				isFields = false
+				break
+			}
+		}
+
+		if isFields {
+			fieldParagraphs = append(fieldParagraphs, strings.Join(paragraph, "\n"))
+		} else {
+			freeForm = append(freeForm, strings.Join(paragraph, "\n"))
+		}
+		paragraph = nil
+	}
+
+	for idx, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+
+		if line == "---" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "diff ") ||
+			strings.HasPrefix(line, "Index: ") || strings.HasPrefix(line, "===") {
+			break
+		}
+
+		if idx == 0 && gitMailHeader.MatchString(line) || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if line == "" {
+			flush()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+
+	var res Header
+	if len(fieldParagraphs) > 0 {
+		if err := deb822.Unmarshal(strings.Join(fieldParagraphs, "\n")+"\n", &res); err != nil {
+			return nil, fmt.Errorf("DEP-3 header: %w", err)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(res.Extra)) {
+		alias, found := headerAliases[strings.ToLower(name)]
+		if !found {
+			continue
+		}
+
+		field := &res.Description
+		if alias == "Author" {
+			field = &res.Author
+		}
+		if *field == "" {
+			*field = res.Extra[name]
+			delete(res.Extra, name)
+		}
+	}
+	res.Description = subjectTag.ReplaceAllString(res.Description, "")
+
+	// " ." continuation lines separate paragraphs of the long description
+	lines := strings.Split(strings.TrimSuffix(res.Description, "\n"), "\n")
+	for idx, line := range lines {
+		if line == "." {
+			lines[idx] = ""
+		}
+	}
+	res.Description = strings.Join(lines, "\n")
+
+	// free-form paragraphs stay separate, the first one continues a bare synopsis
+	if len(freeForm) > 0 {
+		description := strings.Join(freeForm, "\n\n")
+		switch {
+		case res.Description == "":
+			res.Description = description
+		case len(lines) == 1:
+			res.Description += "\n" + description
+		default:
+			res.Description += "\n\n" + description
+		}
+	}
+
+	return &res, nil
+}
+
+// WriteTo writes the header as a deb822 paragraph, followed by the "---" separator. [pkg/io.WriterTo] interface implementation
+func (h *Header) WriteTo(w io.Writer) (int64, error) {
+	text, err := deb822.Marshal(*h)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.WriteString(w, text+"---\n")
+	return int64(n), err
+}
@@ -0,0 +1,93 @@
+package quilt_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/quilt"
+)
+
+func ExampleParseHeader() {
+	h, err := quilt.ParseHeader(`Description: Fix build with GCC 14
+ Implicit function declarations are errors now.
+Origin: upstream, https://example.org/commit/1234
+Bug: https://example.org/issues/42
+Bug-Debian: https://bugs.debian.org/1000000
+Forwarded: not-needed
+Last-Update: 2024-05-01
+
+GCC 14 turned -Wimplicit-function-declaration
+into an error.
+
+Missing headers are included now.
+---
+--- a/main.c
++++ b/main.c
+`)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(h.Description)
+	fmt.Println(h.Origin)
+	fmt.Println(h.Bug, h.Extra["Bug-Debian"])
+	fmt.Println(h.Forwarded, h.LastUpdate)
+
+	// paragraphs are kept when written back
+	h.WriteTo(os.Stdout)
+
+	// Output:
+	// Fix build with GCC 14
+	// Implicit function declarations are errors now.
+	//
+	// GCC 14 turned -Wimplicit-function-declaration
+	// into an error.
+	//
+	// Missing headers are included now.
+	// upstream, https://example.org/commit/1234
+	// https://example.org/issues/42 https://bugs.debian.org/1000000
+	// not-needed 2024-05-01
+	// Description: Fix build with GCC 14
+	//  Implicit function declarations are errors now.
+	//  .
+	//  GCC 14 turned -Wimplicit-function-declaration
+	//  into an error.
+	//  .
+	//  Missing headers are included now.
+	// Origin: upstream, https://example.org/commit/1234
+	// Bug: https://example.org/issues/42
+	// Forwarded: not-needed
+	// Last-Update: 2024-05-01
+	// Bug-Debian: https://bugs.debian.org/1000000
+	// ---
+}
+
+func TestParseHeaderGit(t *testing.T) {
+	h, err := quilt.ParseHeader(`From 0123456789abcdef0123456789abcdef01234567 Mon Sep 17 00:00:00 2001
+From: John Doe <john@example.org>
+Date: Wed, 1 May 2024 10:00:00 +0200
+Subject: [PATCH] Fix typo
+
+Long explanation
+of the change.
+---
+ main.c | 2 +-
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h.Author != "John Doe <john@example.org>" || h.Description != "Fix typo\nLong explanation\nof the change." {
+		t.Fatalf("unexpected header %+v", h)
+	}
+
+	var out strings.Builder
+	h.WriteTo(&out)
+	if !strings.HasPrefix(out.String(), "Description: Fix typo\n Long explanation\n of the change.\nAuthor: John Doe") ||
+		!strings.HasSuffix(out.String(), "\n---\n") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,235 @@
+package quilt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DevNull is the file name of the missing side of a created or deleted file
+const DevNull = "/dev/null"
+
+// Patch is a parsed unified diff
+type Patch struct {
+	Header string // text preceding the first file diff, i.e. DEP-3 header
+	Files  []FileDiff
+}
+
+// FileDiff is a set of changes to a single file
+type FileDiff struct {
+	OldName, NewName string // as written in the patch, DevNull for created and deleted files
+	Hunks            []Hunk
+}
+
+// Hunk is a contiguous change
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+
+	// lines prefixed with ' ', '-' or '+', without line endings
+	Lines []string
+
+	// "\ No newline at end of file" markers
+	OldNoNewline, NewNoNewline bool
+}
+
+/*
+trim returns old and new versions of the hunk text with up to fuzz context lines dropped at either end, along with
+the number of lines dropped at the beginning
+*/
+func (h Hunk) trim(fuzz int) (old, new []string, skipped int) {
+	lines := h.Lines
+
+	for skipped < fuzz && len(lines) > 0 && lines[0][0] == ' ' {
+		lines = lines[1:]
+		skipped++
+	}
+	for dropped := 0; dropped < fuzz && len(lines) > 0 && lines[len(lines)-1][0] == ' '; dropped++ {
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, line := range lines {
+		switch line[0] {
+		case ' ':
+			old = append(old, line[1:])
+			new = append(new, line[1:])
+		case '-':
+			old = append(old, line[1:])
+		case '+':
+			new = append(new, line[1:])
+		}
+	}
+	return
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParsePatch parses unified diff. Git extended headers are skipped, binary patches are not supported
+func ParsePatch(r io.Reader) (*Patch, error) {
+	var res Patch
+	var header strings.Builder
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for idx := 0; idx < len(lines); idx++ {
+		line := lines[idx]
+
+		if !strings.HasPrefix(line, "--- ") || idx+1 >= len(lines) || !strings.HasPrefix(lines[idx+1], "+++ ") {
+			if len(res.Files) == 0 {
+				header.WriteString(line + "\n")
+			}
+			continue
+		}
+
+		file := FileDiff{OldName: fileName(line[4:]), NewName: fileName(lines[idx+1][4:])}
+		idx += 2
+
+		for idx < len(lines) && strings.HasPrefix(lines[idx], "@@ ") {
+			hunk, consumed, err := parseHunk(lines[idx:])
+			if err != nil {
+				return nil, fmt.Errorf("%s, line %d: %w", file.NewName, idx+1, err)
+			}
+			file.Hunks = append(file.Hunks, hunk)
+			idx += consumed
+		}
+		idx--
+
+		res.Files = append(res.Files, file)
+	}
+
+	res.Header = header.String()
+	return &res, nil
+}
+
+// file name without timestamp, which is separated with a tab
+func fileName(s string) string {
+	name, _, _ := strings.Cut(s, "\t")
+	return strings.TrimSpace(name)
+}
+
+// parses a hunk starting with its header, returns number of lines consumed
+func parseHunk(lines []string) (Hunk, int, error) {
+	m := hunkHeader.FindStringSubmatch(lines[0])
+	if m == nil {
+		return Hunk{}, 0, fmt.Errorf("malformed hunk header %s", lines[0])
+	}
+
+	number := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	h := Hunk{OldStart: number(m[1]), OldLines: number(m[2]), NewStart: number(m[3]), NewLines: number(m[4])}
+
+	oldLeft, newLeft := h.OldLines, h.NewLines
+	idx := 1
+	for ; idx < len(lines) && (oldLeft > 0 || newLeft > 0); idx++ {
+		line := lines[idx]
+		if line == "" {
+			// editors tend to strip trailing space of empty context lines
+			line = " "
+		}
+
+		switch line[0] {
+		case ' ':
+			oldLeft--
+			newLeft--
+		case '-':
+			oldLeft--
+		case '+':
+			newLeft--
+		case '\\':
+			h.markNoNewline()
+			continue
+		default:
+			return h, 0, fmt.Errorf("unexpected line in hunk: %s", line)
+		}
+
+		h.Lines = append(h.Lines, line)
+	}
+
+	if oldLeft != 0 || newLeft != 0 {
+		return h, 0, fmt.Errorf("truncated hunk %s", lines[0])
+	}
+
+	// marker may follow the last line
+	if idx < len(lines) && strings.HasPrefix(lines[idx], "\\") {
+		h.markNoNewline()
+		idx++
+	}
+
+	return h, idx, nil
+}
+
+// WriteTo writes the header followed by the unified diff. [pkg/io.WriterTo] interface implementation
+func (p *Patch) WriteTo(w io.Writer) (int64, error) {
+	var buf strings.Builder
+
+	buf.WriteString(p.Header)
+	for _, file := range p.Files {
+		fmt.Fprintf(&buf, "--- %s\n+++ %s\n", file.OldName, file.NewName)
+
+		for _, h := range file.Hunks {
+			fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+
+			lastOld, lastNew := -1, -1
+			for idx, line := range h.Lines {
+				if line[0] != '+' {
+					lastOld = idx
+				}
+				if line[0] != '-' {
+					lastNew = idx
+				}
+			}
+
+			for idx, line := range h.Lines {
+				buf.WriteString(line + "\n")
+				if idx == lastOld && h.OldNoNewline || idx == lastNew && h.NewNoNewline {
+					buf.WriteString("\\ No newline at end of file\n")
+				}
+			}
+		}
+	}
+
+	n, err := io.WriteString(w, buf.String())
+	return int64(n), err
+}
+
+// single line ranges are written without the length
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// "\ No newline at end of file" refers to the preceding line
+func (h *Hunk) markNoNewline() {
+	if len(h.Lines) == 0 {
+		return
+	}
+
+	switch h.Lines[len(h.Lines)-1][0] {
+	case ' ':
+		h.OldNoNewline, h.NewNoNewline = true, true
+	case '-':
+		h.OldNoNewline = true
+	case '+':
+		h.NewNoNewline = true
+	}
+}
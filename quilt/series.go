@@ -0,0 +1,183 @@
+/*
+Package quilt handles patch series, as used by "3.0 (quilt)" source packages in debian/patches: series files, DEP-3
+patch headers, applying patches and regenerating them from changed trees.
+
+https://manpages.debian.org/quilt.1
+*/
+package quilt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SeriesEntry is a line of a series file: a patch, a comment or a blank line
+type SeriesEntry struct {
+	Name    string // empty for lines without a patch
+	Strip   int    // number of leading path components to strip from file names, -p option
+	Comment string // comment including the leading '#', if any
+
+	line string // original text of the line, written back while the entry is unchanged
+}
+
+// Series is a parsed series file. Comments and blank lines are kept, lines of unchanged entries are written back as is
+type Series struct {
+	Entries []SeriesEntry
+}
+
+/*
+ParseSeries parses series file. Patches are applied with -p1 unless told otherwise, other options are not supported.
+
+As dpkg-source does, '#' starts a comment at the beginning of the line or after a whitespace, so it may be a part of
+the patch name otherwise.
+*/
+func ParseSeries(r io.Reader) (*Series, error) {
+	var res Series
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry, err := parseSeriesLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("series line %d: %w", line, err)
+		}
+
+		res.Entries = append(res.Entries, entry)
+	}
+
+	return &res, scanner.Err()
+}
+
+func parseSeriesLine(line string) (SeriesEntry, error) {
+	entry := SeriesEntry{Strip: 1, line: line}
+
+	text := line
+	for i := range len(line) {
+		if line[i] == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			text, entry.Comment = line[:i], line[i:]
+			break
+		}
+	}
+
+	if tokens := strings.Fields(text); len(tokens) > 0 {
+		entry.Name = tokens[0]
+
+		for _, option := range tokens[1:] {
+			level, found := strings.CutPrefix(option, "-p")
+			if !found {
+				return entry, fmt.Errorf("unsupported option %s", option)
+			}
+
+			var err error
+			if entry.Strip, err = strconv.Atoi(level); err != nil {
+				return entry, fmt.Errorf("malformed option %s", option)
+			}
+		}
+	}
+
+	return entry, nil
+}
+
+// ReadSeries parses series file, returning patches only
+func ReadSeries(r io.Reader) ([]SeriesEntry, error) {
+	series, err := ParseSeries(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return series.Patches(), nil
+}
+
+// Patches returns the entries naming patches, in order of application
+func (s *Series) Patches() []SeriesEntry {
+	var res []SeriesEntry
+	for _, entry := range s.Entries {
+		if entry.Name != "" {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
+// Index returns position of the patch in Entries or -1, if it is not there
+func (s *Series) Index(name string) int {
+	return slices.IndexFunc(s.Entries, func(entry SeriesEntry) bool { return entry.Name == name })
+}
+
+// Add appends the patch to the end of the series, unless it is already listed
+func (s *Series) Add(entry SeriesEntry) bool {
+	if s.Index(entry.Name) != -1 {
+		return false
+	}
+
+	s.Entries = append(s.Entries, entry)
+	return true
+}
+
+// Insert puts the patch right after the one named after, or to the top, if after is empty
+func (s *Series) Insert(after string, entry SeriesEntry) error {
+	if s.Index(entry.Name) != -1 {
+		return fmt.Errorf("patch %s is already in the series", entry.Name)
+	}
+
+	pos := 0
+	if after != "" {
+		if pos = s.Index(after); pos == -1 {
+			return fmt.Errorf("patch %s is not in the series", after)
+		}
+		pos++
+	}
+
+	s.Entries = slices.Insert(s.Entries, pos, entry)
+	return nil
+}
+
+// Remove deletes the patch from the series, returns false if it is not there
+func (s *Series) Remove(name string) bool {
+	pos := s.Index(name)
+	if pos == -1 {
+		return false
+	}
+
+	s.Entries = slices.Delete(s.Entries, pos, pos+1)
+	return true
+}
+
+// String formats the entry as a series line. Parsed entries keep their original text, unless they were changed.
+// Otherwise -p1 is the default, so it is omitted
+func (e SeriesEntry) String() string {
+	if e.line != "" {
+		if parsed, err := parseSeriesLine(e.line); err == nil && parsed == e {
+			return e.line
+		}
+	}
+
+	var fields []string
+
+	if e.Name != "" {
+		fields = append(fields, e.Name)
+		if e.Strip != 1 {
+			fields = append(fields, fmt.Sprintf("-p%d", e.Strip))
+		}
+	}
+
+	if e.Comment != "" {
+		fields = append(fields, e.Comment)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// WriteTo writes the series file to w. [pkg/io.WriterTo] interface implementation
+func (s *Series) WriteTo(w io.Writer) (int64, error) {
+	var buf strings.Builder
+	for _, entry := range s.Entries {
+		buf.WriteString(entry.String() + "\n")
+	}
+
+	n, err := io.WriteString(w, buf.String())
+	return int64(n), err
+}
@@ -0,0 +1,106 @@
+package quilt_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/quilt"
+)
+
+func ExampleReadSeries() {
+	series, err := quilt.ReadSeries(strings.NewReader(`# applied in order
+01-fix-build.patch
+02-hurd.patch -p0 # from upstream bug tracker
+
+03-docs.patch
+`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, entry := range series {
+		fmt.Println(entry.Name, entry.Strip)
+	}
+
+	// Output:
+	// 01-fix-build.patch 1
+	// 02-hurd.patch 0
+	// 03-docs.patch 1
+}
+
+func TestSeriesEdit(t *testing.T) {
+	const text = `# applied in order
+01-fix-build.patch
+02-hurd.patch -p0 # from upstream bug tracker
+
+03-docs.patch
+`
+	series, err := quilt.ParseSeries(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	series.WriteTo(&out)
+	if out.String() != text {
+		t.Fatalf("series not preserved:\n%s", out.String())
+	}
+
+	if !series.Remove("01-fix-build.patch") || series.Remove("missing.patch") {
+		t.Fatal("unexpected result of Remove")
+	}
+	if err := series.Insert("02-hurd.patch", quilt.SeriesEntry{Name: "02a-kfreebsd.patch", Strip: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if series.Add(quilt.SeriesEntry{Name: "03-docs.patch", Strip: 1}) {
+		t.Fatal("duplicate patch added")
+	}
+	series.Add(quilt.SeriesEntry{Name: "04-tests.patch", Strip: 2})
+
+	out.Reset()
+	series.WriteTo(&out)
+	expected := `# applied in order
+02-hurd.patch -p0 # from upstream bug tracker
+02a-kfreebsd.patch
+
+03-docs.patch
+04-tests.patch -p2
+`
+	if out.String() != expected {
+		t.Fatalf("unexpected series:\n%s", out.String())
+	}
+}
+
+func TestSeriesMalformed(t *testing.T) {
+	if _, err := quilt.ParseSeries(strings.NewReader("01.patch -R\n")); err == nil {
+		t.Fatal("unsupported option accepted")
+	}
+}
+
+func TestSeriesRaw(t *testing.T) {
+	const text = "01-fix#1.patch   -p1\n  # indented comment\n02-hurd.patch\t-p0\t# tabs\n"
+	series, err := quilt.ParseSeries(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name := series.Entries[0].Name; name != "01-fix#1.patch" {
+		t.Fatalf("'#' inside of the patch name is not a comment, got %s", name)
+	}
+
+	var out strings.Builder
+	series.WriteTo(&out)
+	if out.String() != text {
+		t.Fatalf("series not preserved:\n%q", out.String())
+	}
+
+	// changed entries are formatted anew
+	series.Entries[2].Strip = 2
+	out.Reset()
+	series.WriteTo(&out)
+	if expected := "01-fix#1.patch   -p1\n  # indented comment\n02-hurd.patch -p2 # tabs\n"; out.String() != expected {
+		t.Fatalf("unexpected series:\n%q", out.String())
+	}
+}
@@ -4,72 +4,174 @@ import (
 	"io"
 
 	"github.com/aol-nnov/debian/deb822"
+	"github.com/aol-nnov/debian/fields"
 	"github.com/aol-nnov/debian/internal/stringspp"
+	"github.com/aol-nnov/debian/internal/universalreader"
 )
 
 type BinaryIndex struct {
 	Items []BinaryIndexItem
 
-	byName       map[string]int
-	bySourceName map[string]int
+	// []int for storing indices to multiple versions and architectures of single package
+	byName       map[string][]int
+	bySourceName map[string][]int
+	byKey        map[binaryKey]int
 }
 
+// identity of a binary package in the index
+type binaryKey struct {
+	name, version, arch string
+}
+
+func makeBinaryKey(name string, version fields.Version, arch fields.Architecture) binaryKey {
+	return binaryKey{name, version.String(), arch.String()}
+}
+
+// binary package stanza of Packages index
+// https://wiki.debian.org/DebianRepository/Format#A.22Packages.22_Indices
 type BinaryIndexItem struct {
-	Name           string   `deb822:"Package" required:"true"`
-	Provides       []string `delim:"," strip:" "`
-	Source         string   `if_missing:"Package"`
-	InstalledSize  int      `deb822:"Installed-Size"`
-	DescriptionMd5 string   `deb822:"Description-md5"`
-	Filename       string
-	Size           int
-	MD5sum         string
-	SHA256         string
-	Architecture   string
+	Name         string              `deb822:"Package" required:"true"`
+	Source       fields.Source       `if_missing:"Package"`
+	Version      fields.Version      `required:"true"`
+	Architecture fields.Architecture `required:"true"`
+	MultiArch    fields.MultiArch    `deb822:"Multi-Arch"`
+	Essential    bool
+	Section      string
+	Priority     string
+	Maintainer   fields.Maintainer
+
+	Depends    fields.Dependencies `delim:"," strip:" \n"`
+	PreDepends fields.Dependencies `deb822:"Pre-Depends" delim:"," strip:" \n"`
+	Recommends fields.Dependencies `delim:"," strip:" \n"`
+	Suggests   fields.Dependencies `delim:"," strip:" \n"`
+	Enhances   fields.Dependencies `delim:"," strip:" \n"`
+	Conflicts  fields.Dependencies `delim:"," strip:" \n"`
+	Breaks     fields.Dependencies `delim:"," strip:" \n"`
+	Replaces   fields.Dependencies `delim:"," strip:" \n"`
+	Provides   fields.Dependencies `delim:"," strip:" \n"`
+
+	InstalledSize  int `deb822:"Installed-Size"`
+	Description    fields.Description
+	DescriptionMd5 string `deb822:"Description-md5"`
+	Homepage       string
+	Tag            []string `delim:"," strip:" \n"`
+
+	Filename string
+	Size     int
+	MD5sum   string
+	SHA256   string
+
+	// fields not listed above, i.e. Built-Using or Ruby-Versions
+	Extra map[string]string `deb822:",extra"`
+}
+
+// SourceVersion returns version of the source package the binary was built from
+func (p BinaryIndexItem) SourceVersion() fields.Version {
+	if p.Source.Version != nil {
+		return *p.Source.Version
+	}
+	return p.Version
+}
+
+// Candidate returns package properties relevant for dependency resolution
+func (p BinaryIndexItem) Candidate() fields.Candidate {
+	return fields.Candidate{
+		Name:         p.Name,
+		Version:      p.Version,
+		Architecture: p.Architecture,
+		MultiArch:    p.MultiArch,
+		Provides:     p.Provides,
+	}
 }
 
 func (p BinaryIndexItem) String() string {
 	return stringspp.UniversalStringer(p)
 }
 
+/*
+NewBinaryIndex decodes Packages index.
+
+Packages are identified by name, version and architecture. If the same package is listed more than once, the last
+stanza wins.
+*/
 func NewBinaryIndex(reader io.Reader, inErr error) (*BinaryIndex, error) {
 	if inErr != nil {
 		return nil, inErr
 	}
-	var res BinaryIndex
+	defer universalreader.MaybeClose(reader)
 
-	err := deb822.NewDecoder(reader).Decode(&res.Items)
+	res := BinaryIndex{
+		byName:       make(map[string][]int),
+		bySourceName: make(map[string][]int),
+		byKey:        make(map[binaryKey]int),
+	}
 
-	if s, ok := reader.(io.Closer); ok {
-		s.Close()
+	// stanzas are decoded one by one, so only the resulting items are kept in memory
+	for pkg, err := range deb822.Iter[BinaryIndexItem](deb822.NewDecoder(reader)) {
+		if err != nil {
+			return nil, err
+		}
+
+		key := makeBinaryKey(pkg.Name, pkg.Version, pkg.Architecture)
+		if idx, found := res.byKey[key]; found {
+			res.Items[idx] = pkg
+			continue
+		}
+
+		idx := len(res.Items)
+		res.byKey[key] = idx
+		res.byName[pkg.Name] = append(res.byName[pkg.Name], idx)
+		res.bySourceName[pkg.Source.Name] = append(res.bySourceName[pkg.Source.Name], idx)
+		res.Items = append(res.Items, pkg)
 	}
 
-	if err != nil {
-		return nil, err
+	return &res, nil
+}
+
+func (bi BinaryIndex) items(indices []int) []BinaryIndexItem {
+	res := make([]BinaryIndexItem, 0, len(indices))
+	for _, idx := range indices {
+		res = append(res, bi.Items[idx])
 	}
+	return res
+}
 
-	res.byName = make(map[string]int, len(res.Items))
-	res.bySourceName = make(map[string]int, len(res.Items))
-	for idx, pkg := range res.Items {
-		res.byName[pkg.Name] = idx
-		// res.bySourceName[pkg.Source] = idx
+// FindByName returns all versions and architectures of the binary package name
+func (bi BinaryIndex) FindByName(name string) ([]BinaryIndexItem, bool) {
+	if indices, found := bi.byName[name]; found {
+		return bi.items(indices), true
 	}
 
-	return &res, nil
+	return nil, false
 }
 
-func (bi BinaryIndex) FindByName(name string) (*BinaryIndexItem, bool) {
-
-	if idx, found := bi.byName[name]; found {
+// Find returns exact binary package
+func (bi BinaryIndex) Find(name string, version fields.Version, arch fields.Architecture) (*BinaryIndexItem, bool) {
+	if idx, found := bi.byKey[makeBinaryKey(name, version, arch)]; found {
 		return &bi.Items[idx], true
 	}
 
 	return nil, false
 }
 
-func (bi BinaryIndex) FindBySourceName(name string) (*BinaryIndexItem, bool) {
-	if idx, found := bi.bySourceName[name]; found {
-		return &bi.Items[idx], true
+// FindBySourceName returns all binary packages built from any version of the source package name
+func (bi BinaryIndex) FindBySourceName(name string) ([]BinaryIndexItem, bool) {
+	if indices, found := bi.bySourceName[name]; found {
+		return bi.items(indices), true
 	}
 
 	return nil, false
 }
+
+// FindBySource returns all binary packages built from the given version of the source package name
+func (bi BinaryIndex) FindBySource(name string, version fields.Version) ([]BinaryIndexItem, bool) {
+	var res []BinaryIndexItem
+
+	for _, idx := range bi.bySourceName[name] {
+		if bi.Items[idx].SourceVersion().Compare(version) == fields.VersionCompareResultEquals {
+			res = append(res, bi.Items[idx])
+		}
+	}
+
+	return res, len(res) > 0
+}
@@ -2,9 +2,11 @@ package repo_test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/aol-nnov/debian/deb822"
+	"github.com/aol-nnov/debian/fields"
 	"github.com/aol-nnov/debian/repo"
 )
 
@@ -16,8 +18,126 @@ func TestMissingSourceField(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if ii.Name != ii.Source {
+	if ii.Name != ii.Source.Name {
 		t.Fail()
 	}
 	t.Log(ii)
 }
+
+func TestNewBinaryIndex(t *testing.T) {
+	bi, err := repo.NewBinaryIndex(os.Open("./testdata/binaryIndex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := bi.FindByName("0ad"); !found {
+		t.Fatal("0ad must be found in the index")
+	}
+}
+
+func TestBinaryIndexItem(t *testing.T) {
+	const stanza = `Package: libc6
+Source: glibc (2.36-9)
+Version: 2.36-9+b1
+Architecture: amd64
+Multi-Arch: same
+Maintainer: GNU Libc Maintainers <debian-glibc@lists.debian.org>
+Depends: libgcc-s1
+Breaks: hurd (<< 1:0.9.git20220301-2), nscd (<< 2.36)
+Provides: libc6-x32 (= 2.36-9)
+Built-Using: gcc-12 (= 12.2.0-14)
+Tag: role::shared-lib,
+ suite::gnu
+`
+	var ii repo.BinaryIndexItem
+	if err := deb822.NewDecoder(strings.NewReader(stanza)).Decode(&ii); err != nil {
+		t.Fatal(err)
+	}
+
+	if ii.Source.Name != "glibc" || ii.SourceVersion().String() != "2.36-9" || ii.Version.String() != "2.36-9+b1" {
+		t.Fatalf("wrong source %v %v", ii.Source, ii.Version)
+	}
+
+	if ii.MultiArch != fields.MultiArchSame || ii.Maintainer.Email != "debian-glibc@lists.debian.org" {
+		t.Fatalf("wrong Multi-Arch or Maintainer %v %v", ii.MultiArch, ii.Maintainer)
+	}
+
+	if len(ii.Breaks) != 2 || ii.Breaks[1].Name != "nscd" || ii.Provides[0].VersionConstraint == nil {
+		t.Fatalf("wrong relations %v %v", ii.Breaks, ii.Provides)
+	}
+
+	if len(ii.Tag) != 2 || ii.Extra["Built-Using"] != "gcc-12 (= 12.2.0-14)" {
+		t.Fatalf("wrong Tag or Extra %v %v", ii.Tag, ii.Extra)
+	}
+}
+
+const multiVersionPackages = `Package: libc6
+Source: glibc
+Version: 2.36-9
+Architecture: amd64
+
+Package: libc6
+Source: glibc
+Version: 2.36-9
+Architecture: i386
+
+Package: libc6
+Source: glibc
+Version: 2.37-1
+Architecture: amd64
+
+Package: libc-bin
+Source: glibc (2.36-9)
+Version: 2.36-9+b1
+Architecture: amd64
+
+Package: libc6
+Source: glibc
+Version: 2.36-9
+Architecture: i386
+Size: 42
+`
+
+func TestBinaryIndexMultiVersion(t *testing.T) {
+	bi, err := repo.NewBinaryIndex(strings.NewReader(multiVersionPackages), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if items, _ := bi.FindByName("libc6"); len(items) != 3 {
+		t.Fatalf("3 versions and architectures of libc6 expected, got %d", len(items))
+	}
+
+	if item, found := bi.Find("libc6", fields.MakeVersion("2.36-9"), fields.MakeArch("i386")); !found || item.Size != 42 {
+		t.Fatalf("last stanza of the duplicate must win, got %v", item)
+	}
+
+	if items, _ := bi.FindBySourceName("glibc"); len(items) != 4 {
+		t.Fatalf("4 binaries of glibc expected, got %d", len(items))
+	}
+
+	items, _ := bi.FindBySource("glibc", fields.MakeVersion("2.36-9"))
+	if len(items) != 3 || items[2].Name != "libc-bin" {
+		t.Fatalf("binaries of glibc 2.36-9 expected, got %v", items)
+	}
+
+	if _, found := bi.FindBySource("glibc", fields.MakeVersion("2.35-1")); found {
+		t.Fatal("there are no binaries of glibc 2.35-1")
+	}
+}
+
+func TestBinaryIndexItemMalformedMaintainer(t *testing.T) {
+	const stanza = `Package: hello
+Version: 1.0-1
+Architecture: amd64
+Maintainer: Debian Hello Team
+`
+	var item repo.BinaryIndexItem
+	if err := deb822.Unmarshal(stanza, &item); err != nil {
+		t.Fatal(err)
+	}
+
+	if item.Maintainer.Name != "Debian Hello Team" || item.Maintainer.Email != "" {
+		t.Fatalf("unexpected maintainer %#v", item.Maintainer)
+	}
+}
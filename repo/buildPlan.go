@@ -0,0 +1,256 @@
+package repo
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aol-nnov/debian/fields"
+)
+
+// BuildEdge is a build dependency of source package From on binary package Binary, which is built from source To
+type BuildEdge struct {
+	From   string
+	To     string
+	Binary string
+}
+
+func (e BuildEdge) String() string {
+	return fmt.Sprintf("%s -> %s (%s)", e.From, e.To, e.Binary)
+}
+
+// Cycle is a strongly connected component of the build dependency graph
+type Cycle struct {
+	Sources []string
+	Edges   []BuildEdge // dependencies between Sources, which form the cycle
+}
+
+func (c Cycle) String() string {
+	return strings.Join(c.Sources, ", ")
+}
+
+// BuildMode selects build dependency fields to consider
+type BuildMode int
+
+const (
+	BuildAll       BuildMode = iota // Build-Depends, Build-Depends-Arch and Build-Depends-Indep
+	BuildArchOnly                   // Build-Depends and Build-Depends-Arch, like dpkg-buildpackage -B does
+	BuildIndepOnly                  // Build-Depends and Build-Depends-Indep, like dpkg-buildpackage -A does
+)
+
+/*
+BuildOptions narrow build dependencies down to the ones, which are relevant for the build. The zero value considers
+all build dependencies with no restrictions applied, as if no profile is active.
+
+If HostArch is set, architecture restrictions (i.e. [amd64 !i386]) are applied. If BuildArch is set and differs from
+HostArch (cross build), dependencies qualified with :native are skipped, as they are satisfied by the build machine.
+
+Restriction formulas (i.e. <!nocheck> or <stage1>) are evaluated against Profiles.
+*/
+type BuildOptions struct {
+	BuildArch fields.Architecture
+	HostArch  fields.Architecture
+	Profiles  []string
+	Mode      BuildMode
+}
+
+/*
+Relations selects build relations (i.e. Build-Depends or Build-Conflicts with their -Arch and -Indep counterparts)
+according to Mode and reduces them with [fields.Dependencies.For], so alternatives, which do not apply because of
+architecture or profile restrictions, are dropped.
+*/
+func (o BuildOptions) Relations(common, arch, indep fields.Dependencies) fields.Dependencies {
+	var all fields.Dependencies
+	switch o.Mode {
+	case BuildArchOnly:
+		all = slices.Concat(common, arch)
+	case BuildIndepOnly:
+		all = slices.Concat(common, indep)
+	default:
+		all = slices.Concat(common, arch, indep)
+	}
+
+	return all.For(o.BuildArch, o.HostArch, o.Profiles)
+}
+
+// returns build dependencies of pkg relevant for the build order
+func (o BuildOptions) buildDepends(pkg SourceIndexItem) fields.Dependencies {
+	noArch := fields.Architecture{}
+	cross := o.BuildArch != noArch && o.HostArch != noArch && !o.BuildArch.Equals(o.HostArch)
+
+	return slices.DeleteFunc(o.Relations(pkg.BuildDepends, pkg.BuildDependsArch, pkg.BuildDependsIndep),
+		func(dep fields.Dependency) bool { return cross && dep.ArchQualifier == "native" })
+}
+
+/*
+BuildPlan is a result of [SourceIndex.BuildPlan].
+
+Source packages of a wave depend only on the ones of the previous waves, so they can be built in parallel. Sources
+forming a cycle can not be ordered, so they are put to the same wave and listed in Cycles. They have to be
+bootstrapped somehow (i.e. with build profiles) before the next wave.
+*/
+type BuildPlan struct {
+	Waves   [][]string
+	Cycles  []Cycle
+	Missing []string // binary packages, which are not built from any source package of the index
+}
+
+// builds dependency graph of the highest versions of source packages
+func (si SourceIndex) buildGraph(opts BuildOptions) (edges map[string][]BuildEdge, missing []string) {
+	edges = make(map[string][]BuildEdge, len(si.byName))
+	missingSet := make(map[string]bool)
+
+	for pkgName := range si.byName {
+		pkg := si.latest(pkgName)
+
+		for _, binDependency := range opts.buildDepends(pkg) {
+			srcDeps, found := si.FindByConstraint(binDependency)
+			if !found {
+				missingSet[binDependency.Name] = true
+				continue
+			}
+
+			// self dependency (i.e. on a previous version of itself) does not affect the order
+			if srcDeps[0].Name == pkgName {
+				continue
+			}
+
+			edges[pkgName] = append(edges[pkgName], BuildEdge{pkgName, srcDeps[0].Name, binDependency.Name})
+		}
+	}
+
+	return edges, slices.Sorted(maps.Keys(missingSet))
+}
+
+// returns the highest version of source package name
+func (si SourceIndex) latest(name string) SourceIndexItem {
+	indices := si.byName[name]
+
+	res := si.Packages[indices[0]]
+	for _, idx := range indices[1:] {
+		if res.Version.Less(si.Packages[idx].Version) {
+			res = si.Packages[idx]
+		}
+	}
+	return res
+}
+
+/*
+BuildPlan splits source packages of the index into build waves. The highest version of each source package is
+considered. Build dependencies are filtered according to opts and resolved with [SourceIndex.FindByConstraint].
+
+Cycles are found with Tarjan's strongly connected components algorithm. To bootstrap a new architecture, activate stage
+profiles (i.e. stage1 and nocheck), which break cycles.
+*/
+func (si SourceIndex) BuildPlan(opts BuildOptions) BuildPlan {
+	edges, missing := si.buildGraph(opts)
+
+	plan := planWaves(slices.Collect(maps.Keys(si.byName)), edges)
+	plan.Missing = missing
+
+	return plan
+}
+
+// splits sources into waves, edges must not lead outside of sources
+func planWaves(sources []string, edges map[string][]BuildEdge) BuildPlan {
+	t := tarjan{
+		edges:   edges,
+		index:   make(map[string]int, len(sources)),
+		low:     make(map[string]int, len(sources)),
+		onStack: make(map[string]bool),
+	}
+	for _, name := range slices.Sorted(slices.Values(sources)) {
+		if _, visited := t.index[name]; !visited {
+			t.connect(name)
+		}
+	}
+
+	var plan BuildPlan
+
+	// components are found in reverse topological order, so dependencies come first
+	component := make(map[string]int, len(sources))
+	level := make([]int, len(t.components))
+
+	for idx, sources := range t.components {
+		for _, name := range sources {
+			component[name] = idx
+		}
+
+		var cycle Cycle
+		for _, name := range sources {
+			for _, edge := range edges[name] {
+				if depIdx := component[edge.To]; depIdx != idx {
+					level[idx] = max(level[idx], level[depIdx]+1)
+				} else {
+					cycle.Edges = append(cycle.Edges, edge)
+				}
+			}
+		}
+
+		if len(sources) > 1 {
+			slices.Sort(sources)
+			cycle.Sources = sources
+			plan.Cycles = append(plan.Cycles, cycle)
+		}
+
+		for len(plan.Waves) <= level[idx] {
+			plan.Waves = append(plan.Waves, nil)
+		}
+		plan.Waves[level[idx]] = append(plan.Waves[level[idx]], sources...)
+	}
+
+	for _, wave := range plan.Waves {
+		slices.Sort(wave)
+	}
+
+	slices.SortFunc(plan.Cycles, func(a, b Cycle) int {
+		return strings.Compare(a.Sources[0], b.Sources[0])
+	})
+
+	return plan
+}
+
+// Tarjan's strongly connected components algorithm state
+type tarjan struct {
+	edges      map[string][]BuildEdge
+	index      map[string]int
+	low        map[string]int
+	stack      []string
+	onStack    map[string]bool
+	components [][]string
+}
+
+func (t *tarjan) connect(name string) {
+	t.index[name] = len(t.index)
+	t.low[name] = t.index[name]
+	t.stack = append(t.stack, name)
+	t.onStack[name] = true
+
+	for _, edge := range t.edges[name] {
+		if _, visited := t.index[edge.To]; !visited {
+			t.connect(edge.To)
+			t.low[name] = min(t.low[name], t.low[edge.To])
+		} else if t.onStack[edge.To] {
+			t.low[name] = min(t.low[name], t.index[edge.To])
+		}
+	}
+
+	if t.low[name] != t.index[name] {
+		return
+	}
+
+	// name is a root of the component
+	var component []string
+	for {
+		last := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		t.onStack[last] = false
+		component = append(component, last)
+
+		if last == name {
+			break
+		}
+	}
+	t.components = append(t.components, component)
+}
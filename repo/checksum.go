@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// IndexFile is a record of MD5Sum, SHA1 or SHA256 list of Release file
+type IndexFile struct {
+	Checksum string
+	Size     int64
+	Path     string // relative to dists/<codename>
+}
+
+func (f *IndexFile) UnmarshalText(text []byte) (err error) {
+	tmp := bytes.Fields(text)
+	if len(tmp) != 3 {
+		return fmt.Errorf("unable to unmarshal IndexFile record '%s'", text)
+	}
+
+	f.Checksum = string(tmp[0])
+	if f.Size, err = strconv.ParseInt(string(tmp[1]), 10, 64); err != nil {
+		return fmt.Errorf("unable to unmarshal IndexFile record '%s': %w", text, err)
+	}
+	f.Path = string(tmp[2])
+
+	return nil
+}
+
+// hash functions of Release checksum lists by field name
+var checksumHashes = map[string]func() hash.Hash{
+	"SHA256": sha256.New,
+	"SHA1":   sha1.New,
+	"MD5Sum": md5.New,
+}
+
+// ChecksumError is returned when fetched index file does not match its Release record
+type ChecksumError struct {
+	Path             string
+	ExpectedSize     int64
+	ActualSize       int64
+	Algorithm        string // SHA256, SHA1 or MD5Sum, the strongest one listed in Release
+	ExpectedChecksum string
+	ActualChecksum   string
+}
+
+func (e *ChecksumError) Error() string {
+	if e.ExpectedSize != e.ActualSize {
+		return fmt.Sprintf("%s: size mismatch, expected %d, got %d", e.Path, e.ExpectedSize, e.ActualSize)
+	}
+	return fmt.Sprintf("%s: %s mismatch, expected %s, got %s", e.Path, e.Algorithm, e.ExpectedChecksum, e.ActualChecksum)
+}
+
+/*
+Checks size and checksum of the data read through it against the Release record.
+
+Mismatch is reported instead of io.EOF. Close drains the rest of the stream, so the whole file is verified even if
+the consumer stopped reading early, and reports the mismatch too.
+*/
+type verifiedReader struct {
+	src       io.ReadCloser
+	expected  IndexFile
+	algorithm string
+	hash      hash.Hash
+	size      int64
+	err       error // verification result, set at EOF
+	done      bool
+	closed    bool
+}
+
+func newVerifiedReader(src io.ReadCloser, expected IndexFile, algorithm string) *verifiedReader {
+	return &verifiedReader{
+		src:       src,
+		expected:  expected,
+		algorithm: algorithm,
+		hash:      checksumHashes[algorithm](),
+	}
+}
+
+func (r *verifiedReader) Read(p []byte) (int, error) {
+	if r.done {
+		if r.err != nil {
+			return 0, r.err
+		}
+		return 0, io.EOF
+	}
+
+	n, err := r.src.Read(p)
+	r.hash.Write(p[:n])
+	r.size += int64(n)
+
+	if r.size > r.expected.Size {
+		r.done = true
+		r.err = r.mismatch()
+		return n, r.err
+	}
+
+	if err == io.EOF {
+		r.done = true
+		if r.size != r.expected.Size || !strings.EqualFold(hex.EncodeToString(r.hash.Sum(nil)), r.expected.Checksum) {
+			r.err = r.mismatch()
+			return n, r.err
+		}
+	}
+
+	return n, err
+}
+
+func (r *verifiedReader) mismatch() *ChecksumError {
+	return &ChecksumError{
+		Path:             r.expected.Path,
+		ExpectedSize:     r.expected.Size,
+		ActualSize:       r.size,
+		Algorithm:        r.algorithm,
+		ExpectedChecksum: r.expected.Checksum,
+		ActualChecksum:   hex.EncodeToString(r.hash.Sum(nil)),
+	}
+}
+
+// Close drains the stream and closes the source. Returns [*ChecksumError] on mismatch. It is safe to call it again
+func (r *verifiedReader) Close() error {
+	if r.closed {
+		return r.err
+	}
+	r.closed = true
+
+	_, err := io.Copy(io.Discard, r)
+	if closeErr := r.src.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
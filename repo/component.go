@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aol-nnov/debian/internal/universalreader"
@@ -14,23 +15,36 @@ type Component struct {
 	name string
 }
 
-func (c *Component) SourceIndex() (*SourceIndex, error) {
-	indexFile := fmt.Sprintf("%s/dists/%s/%s/source/Sources.gz",
-		c.repo.baseUrl,
-		c.repo.Codename,
-		c.name)
+// SourceIndex fetches and parses Sources index of the component. Index is verified against Release, see [ChecksumError]
+func (c *Component) SourceIndex(ctx context.Context) (*SourceIndex, error) {
+	reader, err := c.repo.openIndex(ctx, fmt.Sprintf("%s/source/Sources", c.name))
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := NewSourceIndex(universalreader.Decompress(reader))
 
-	return NewSourceIndex(universalreader.New(indexFile))
+	// checksum mismatch takes precedence over decoding errors, as it is the reason of the latter most of the time
+	if verifyErr := reader.Close(); verifyErr != nil {
+		return nil, verifyErr
+	}
+	return index, err
 }
 
-func (c *Component) BinaryIndex(arch string) (*BinaryIndex, error) {
+// BinaryIndex fetches and parses Packages index of the component. Index is verified against Release, see [ChecksumError]
+func (c *Component) BinaryIndex(ctx context.Context, arch string) (*BinaryIndex, error) {
 	if slices.Contains(c.repo.Architectures, arch) {
-		indexFile := fmt.Sprintf("%s/dists/%s/%s/binary-%s/Packages.gz",
-			c.repo.baseUrl,
-			c.repo.Codename,
-			c.name, arch)
+		reader, err := c.repo.openIndex(ctx, fmt.Sprintf("%s/binary-%s/Packages", c.name, arch))
+		if err != nil {
+			return nil, err
+		}
+
+		index, err := NewBinaryIndex(universalreader.Decompress(reader))
 
-		return NewBinaryIndex(universalreader.New(indexFile))
+		if verifyErr := reader.Close(); verifyErr != nil {
+			return nil, verifyErr
+		}
+		return index, err
 	}
 	return nil, fmt.Errorf("%s %s no such architecture %s", c.repo.baseUrl, c.name, arch)
 }
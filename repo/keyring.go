@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+/*
+Loads OpenPGP public keys from path, which is either a keyring file or a directory of keyring files, like
+/etc/apt/trusted.gpg.d. Both armored and binary keyrings are accepted. In a directory, only *.asc and *.gpg files
+are considered.
+*/
+func loadKeyring(path string) (openpgp.EntityList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return loadKeyringFile(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyring openpgp.EntityList
+	for _, entry := range entries {
+		if entry.IsDir() || !slices.Contains([]string{".asc", ".gpg"}, filepath.Ext(entry.Name())) {
+			continue
+		}
+
+		keys, err := loadKeyringFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keyring = append(keyring, keys...)
+	}
+
+	if len(keyring) == 0 {
+		return nil, fmt.Errorf("%s: no keys found", path)
+	}
+
+	return keyring, nil
+}
+
+func loadKeyringFile(path string) (openpgp.EntityList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyring openpgp.EntityList
+	if isArmored(data) {
+		keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	} else {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return keyring, nil
+}
+
+func isArmored(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP"))
+}
+
+// verifies detached signature of signed data
+func checkDetachedSignature(keyring openpgp.KeyRing, signed, signature []byte) (*openpgp.Entity, error) {
+	if isArmored(signature) {
+		return openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(signed), bytes.NewReader(signature), nil)
+	}
+	return openpgp.CheckDetachedSignature(keyring, bytes.NewReader(signed), bytes.NewReader(signature), nil)
+}
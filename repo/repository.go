@@ -1,17 +1,30 @@
 package repo
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"path"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"golang.org/x/exp/slices"
 
 	"github.com/aol-nnov/debian/deb822"
+	"github.com/aol-nnov/debian/fetch"
 	"github.com/aol-nnov/debian/internal/stringspp"
-	"github.com/aol-nnov/debian/internal/universalreader"
 )
 
+// ErrNotSigned is returned by [New], if a keyring is given, but the repository is not signed
+var ErrNotSigned = errors.New("repository is not signed")
+
 type Repository struct {
 	baseUrl                     string
+	keyring                     openpgp.EntityList
+	fetcher                     fetch.Fetcher
+	cacheDir                    string
 	Origin                      string
 	Label                       string
 	Suite                       string
@@ -24,29 +37,227 @@ type Repository struct {
 	Architectures               []string `delim:" "`
 	Components                  []string `delim:" "`
 	Description                 string
+	MD5Sum                      []IndexFile `delim:"\n" strip:" \n"`
+	SHA1                        []IndexFile `delim:"\n" strip:" \n"`
+	SHA256                      []IndexFile `delim:"\n" strip:" \n"`
+}
+
+// Option configures [New]
+type Option func(*Repository) error
+
+/*
+WithKeyring makes [New] verify repository signature with the keys from path, which is either a keyring file
+or a directory of keyring files, like /etc/apt/trusted.gpg.d. Armored (*.asc) and binary (*.gpg) keyrings are
+supported.
+*/
+func WithKeyring(path string) Option {
+	return func(r *Repository) error {
+		keyring, err := loadKeyring(path)
+		if err != nil {
+			return err
+		}
+		r.keyring = append(r.keyring, keyring...)
+		return nil
+	}
+}
+
+// WithFetcher sets transport used to retrieve repository files. [fetch.Default] is used otherwise
+func WithFetcher(fetcher fetch.Fetcher) Option {
+	return func(r *Repository) error {
+		r.fetcher = fetcher
+		return nil
+	}
+}
+
+/*
+WithCacheDir makes repository keep fetched files in dir. Cached index files are not downloaded again, if their hash
+matches Release, other files are revalidated with a conditional request, if transport supports it.
+*/
+func WithCacheDir(dir string) Option {
+	return func(r *Repository) error {
+		r.cacheDir = dir
+		return nil
+	}
 }
 
-func New(baseUrl, codename string) (*Repository, error) {
-	var err error
+/*
+New reads repository metadata from dists/<codename>/Release. baseUrl is either http(s) url or a local directory, unless
+a custom fetcher is set with [WithFetcher].
+
+Without a keyring, Release file is trusted as is. With [WithKeyring], InRelease is preferred and Release with Release.gpg
+detached signature is a fallback, if InRelease is missing. Unsigned repositories are refused with [ErrNotSigned], as
+well as the ones with a signature, which can not be verified with the keyring.
+
+Index files are verified against the strongest checksum list of Release: SHA256, SHA1 or MD5Sum.
+*/
+func New(ctx context.Context, baseUrl, codename string, opts ...Option) (*Repository, error) {
+	repository := Repository{
+		baseUrl: baseUrl,
+		fetcher: fetch.Default(),
+	}
+
+	for _, opt := range opts {
+		if err := opt(&repository); err != nil {
+			return nil, err
+		}
+	}
 
-	repoReader, err := universalreader.New(
-		fmt.Sprintf("%s/dists/%s/Release", baseUrl, codename))
-	defer universalreader.MaybeClose(repoReader)
+	if repository.cacheDir != "" {
+		repository.fetcher = &fetch.Cache{Dir: repository.cacheDir, Fetcher: repository.fetcher}
+	}
 
+	release, err := repository.release(ctx, codename)
 	if err != nil {
 		return nil, err
 	}
 
-	var repository Repository
-	err = deb822.NewDecoder(repoReader).Decode(&repository)
-	if err != nil {
+	if err = deb822.NewDecoder(bytes.NewReader(release)).Decode(&repository); err != nil {
 		return nil, err
 	}
-	repository.baseUrl = baseUrl
 
 	return &repository, nil
 }
 
+// fetches Release file contents, verifying its signature when keyring is set
+func (r *Repository) release(ctx context.Context, codename string) ([]byte, error) {
+	distUrl := fmt.Sprintf("%s/dists/%s", r.baseUrl, codename)
+
+	if r.keyring == nil {
+		return r.readAll(ctx, distUrl+"/Release")
+	}
+
+	// only missing InRelease falls back to Release, other errors (i.e. cancellation) are reported as is
+	inRelease, err := r.readAll(ctx, distUrl+"/InRelease")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if err == nil {
+		decoder := deb822.NewDecoder(bytes.NewReader(inRelease))
+
+		signature := decoder.Signature()
+		if signature == nil {
+			return nil, fmt.Errorf("%s/InRelease: %w", distUrl, ErrNotSigned)
+		}
+
+		if _, err := signature.Verify(r.keyring); err != nil {
+			return nil, fmt.Errorf("%s/InRelease: bad signature: %w", distUrl, err)
+		}
+
+		return inRelease, nil
+	}
+
+	release, err := r.readAll(ctx, distUrl+"/Release")
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := r.readAll(ctx, distUrl+"/Release.gpg")
+	if err != nil {
+		return nil, fmt.Errorf("%s/Release: %w (%w)", distUrl, ErrNotSigned, err)
+	}
+
+	if _, err := checkDetachedSignature(r.keyring, release, signature); err != nil {
+		return nil, fmt.Errorf("%s/Release: bad signature: %w", distUrl, err)
+	}
+
+	return release, nil
+}
+
+func (r *Repository) readAll(ctx context.Context, url string) ([]byte, error) {
+	reader, err := r.fetcher.Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// returns the strongest checksum list of Release along with its name
+func (r *Repository) checksumList() (string, []IndexFile) {
+	switch {
+	case len(r.SHA256) > 0:
+		return "SHA256", r.SHA256
+	case len(r.SHA1) > 0:
+		return "SHA1", r.SHA1
+	default:
+		return "MD5Sum", r.MD5Sum
+	}
+}
+
+// returns record for file name relative to dists/<codename> from the list
+func indexFile(list []IndexFile, name string) (IndexFile, bool) {
+	for _, f := range list {
+		if f.Path == name {
+			return f, true
+		}
+	}
+	return IndexFile{}, false
+}
+
+// compression formats of index files in order of preference, empty one stands for uncompressed file
+var indexCompressions = []string{".xz", ".bz2", ".gz", ""}
+
+/*
+Opens index file name relative to dists/<codename> (without compression extension), which is verified against
+the strongest checksum list of Release (SHA256, SHA1 or MD5Sum) while read.
+
+The best compression listed in Release is picked, missing files fall back to the next one. Files not listed in Release
+are refused.
+*/
+func (r *Repository) openIndex(ctx context.Context, name string) (io.ReadCloser, error) {
+	var lastErr error
+
+	algorithm, list := r.checksumList()
+	for _, ext := range indexCompressions {
+		expected, found := indexFile(list, name+ext)
+		if !found {
+			continue
+		}
+
+		reader, err := r.fetchIndex(ctx, expected, algorithm)
+		if err == nil {
+			return newVerifiedReader(reader, expected, algorithm), nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, fmt.Errorf("%s/dists/%s/%s: not listed in Release", r.baseUrl, r.Codename, name)
+}
+
+// with Acquire-By-Hash, index is fetched from by-hash/<algorithm>/<digest> first, so it's consistent with Release
+// even if the mirror is in the middle of sync
+func (r *Repository) fetchIndex(ctx context.Context, f IndexFile, algorithm string) (io.ReadCloser, error) {
+	if r.AcquireByHash {
+		reader, err := r.fetchHash(ctx,
+			fmt.Sprintf("%s/dists/%s/%s/by-hash/%s/%s", r.baseUrl, r.Codename, path.Dir(f.Path), algorithm, f.Checksum),
+			f.Checksum, algorithm)
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return reader, err
+		}
+	}
+
+	return r.fetchHash(ctx, fmt.Sprintf("%s/dists/%s/%s", r.baseUrl, r.Codename, f.Path), f.Checksum, algorithm)
+}
+
+// known SHA256 lets caching fetcher skip the download
+func (r *Repository) fetchHash(ctx context.Context, url, checksum, algorithm string) (io.ReadCloser, error) {
+	if hashFetcher, ok := r.fetcher.(fetch.HashFetcher); ok && algorithm == "SHA256" {
+		return hashFetcher.FetchHash(ctx, url, checksum)
+	}
+	return r.fetcher.Fetch(ctx, url)
+}
+
 func (r Repository) String() string {
 	return stringspp.UniversalStringer(r)
 }
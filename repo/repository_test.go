@@ -1,22 +1,354 @@
 package repo_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/aol-nnov/debian/fetch"
 	"github.com/aol-nnov/debian/repo"
 )
 
 func TestNewRepo(t *testing.T) {
-	repo, err := repo.New("https://mirror.yandex.ru/debian/", "bookworm")
+	repo, err := repo.New(context.Background(), "https://mirror.yandex.ru/debian/", "bookworm")
 
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	si, _ := repo.Component("main").SourceIndex()
+	si, _ := repo.Component("main").SourceIndex(context.Background())
 	fmt.Println(si.FindByName("doxygen"))
 
-	// bi, _ := repo.Component("main").BinaryIndex("amd64")
+	// bi, _ := repo.Component("main").BinaryIndex(context.Background(), "amd64")
 	// fmt.Println(bi.FindByName("mc"))
 }
+
+const testRelease = `Origin: Test
+Codename: test
+Architectures: amd64
+Components: main
+`
+
+// creates a local repository with Release signed by a fresh key according to layout, returns the path to its keyring
+func signedRepo(t *testing.T, layout string) (baseUrl, keyringPath string) {
+	entity, err := openpgp.NewEntity("Test Archive", "", "archive@example.net", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseUrl = t.TempDir()
+	dist := filepath.Join(baseUrl, "dists", "test")
+	os.MkdirAll(dist, 0o755)
+
+	switch layout {
+	case "InRelease":
+		var buf bytes.Buffer
+		w, _ := clearsign.Encode(&buf, entity.PrivateKey, nil)
+		w.Write([]byte(testRelease))
+		w.Close()
+		os.WriteFile(filepath.Join(dist, "InRelease"), buf.Bytes(), 0o644)
+	case "Release.gpg":
+		var buf bytes.Buffer
+		openpgp.ArmoredDetachSignText(&buf, entity, strings.NewReader(testRelease), nil)
+		os.WriteFile(filepath.Join(dist, "Release.gpg"), buf.Bytes(), 0o644)
+		fallthrough
+	default:
+		os.WriteFile(filepath.Join(dist, "Release"), []byte(testRelease), 0o644)
+	}
+
+	keyringDir := t.TempDir()
+	keyringPath = filepath.Join(keyringDir, "test.asc")
+	keyring, _ := os.Create(keyringPath)
+	defer keyring.Close()
+
+	w, _ := armor.Encode(keyring, openpgp.PublicKeyType, nil)
+	entity.Serialize(w)
+	w.Close()
+
+	return baseUrl, keyringPath
+}
+
+func TestNewSigned(t *testing.T) {
+	for _, layout := range []string{"InRelease", "Release.gpg"} {
+		baseUrl, keyringPath := signedRepo(t, layout)
+
+		// both keyring file and a directory of keyrings are fine
+		for _, keyring := range []string{keyringPath, filepath.Dir(keyringPath)} {
+			r, err := repo.New(context.Background(), baseUrl, "test", repo.WithKeyring(keyring))
+			if err != nil {
+				t.Fatal(layout, err)
+			}
+
+			if r.Origin != "Test" || r.Component("main") == nil {
+				t.Fatalf("%s: unexpected repository %v", layout, r)
+			}
+		}
+	}
+}
+
+func TestNewUnsigned(t *testing.T) {
+	baseUrl, keyringPath := signedRepo(t, "Release")
+
+	if _, err := repo.New(context.Background(), baseUrl, "test", repo.WithKeyring(keyringPath)); !errors.Is(err, repo.ErrNotSigned) {
+		t.Fatalf("unsigned repository must be refused, got %v", err)
+	}
+
+	if _, err := repo.New(context.Background(), baseUrl, "test"); err != nil {
+		t.Fatalf("repository must be trusted without a keyring, got %v", err)
+	}
+}
+
+func TestNewBadSignature(t *testing.T) {
+	for _, layout := range []string{"InRelease", "Release.gpg"} {
+		baseUrl, _ := signedRepo(t, layout)
+		_, otherKeyring := signedRepo(t, layout)
+
+		if _, err := repo.New(context.Background(), baseUrl, "test", repo.WithKeyring(otherKeyring)); err == nil {
+			t.Fatalf("%s: repository signed with unknown key must be refused", layout)
+		}
+	}
+}
+
+// creates unsigned local repository with the given index files listed in Release
+func localRepo(t *testing.T, files map[string][]byte) string {
+	baseUrl := t.TempDir()
+	dist := filepath.Join(baseUrl, "dists", "test")
+
+	release := testRelease + "SHA256:\n"
+	for path, data := range files {
+		os.MkdirAll(filepath.Join(dist, filepath.Dir(path)), 0o755)
+		os.WriteFile(filepath.Join(dist, path), data, 0o644)
+		release += fmt.Sprintf(" %x %d %s\n", sha256.Sum256(data), len(data), path)
+	}
+	os.WriteFile(filepath.Join(dist, "Release"), []byte(release), 0o644)
+
+	return baseUrl
+}
+
+func gzipped(text string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(text))
+	w.Close()
+	return buf.Bytes()
+}
+
+const testSources = `Package: hello
+Binary: hello
+Version: 2.10-3
+Architecture: any
+`
+
+func TestIndexChecksum(t *testing.T) {
+	baseUrl := localRepo(t, map[string][]byte{
+		"main/source/Sources.gz":        gzipped(testSources),
+		"main/binary-amd64/Packages.gz": gzipped("Package: hello\nVersion: 2.10-3\nArchitecture: amd64\n"),
+	})
+
+	r, err := repo.New(context.Background(), baseUrl, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.SHA256) != 2 || r.SHA256[0].Size == 0 {
+		t.Fatalf("unexpected SHA256 list %v", r.SHA256)
+	}
+
+	si, err := r.Component("main").SourceIndex(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := si.FindByName("hello"); !found {
+		t.Fatal("hello must be found in the index")
+	}
+
+	if _, err := r.Component("main").BinaryIndex(context.Background(), "amd64"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexChecksumMismatch(t *testing.T) {
+	baseUrl := localRepo(t, map[string][]byte{
+		"main/source/Sources.gz": gzipped(testSources),
+	})
+
+	r, err := repo.New(context.Background(), baseUrl, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := filepath.Join(baseUrl, "dists", "test", "main", "source", "Sources.gz")
+
+	// same size, different contents
+	os.WriteFile(sources, gzipped(strings.Replace(testSources, "2.10-3", "2.10-4", 1)), 0o644)
+
+	var checksumErr *repo.ChecksumError
+	if _, err := r.Component("main").SourceIndex(context.Background()); !errors.As(err, &checksumErr) || checksumErr.ActualChecksum == checksumErr.ExpectedChecksum {
+		t.Fatalf("checksum mismatch must be reported, got %v", err)
+	}
+
+	// truncated
+	data, _ := os.ReadFile(sources)
+	os.WriteFile(sources, data[:len(data)/2], 0o644)
+
+	if _, err := r.Component("main").SourceIndex(context.Background()); !errors.As(err, &checksumErr) || checksumErr.ActualSize != int64(len(data)/2) {
+		t.Fatalf("size mismatch must be reported, got %v", err)
+	}
+}
+
+func TestIndexCompressionFallback(t *testing.T) {
+	baseUrl := localRepo(t, map[string][]byte{
+		"main/source/Sources":    []byte(testSources),
+		"main/source/Sources.gz": gzipped(testSources),
+	})
+
+	r, err := repo.New(context.Background(), baseUrl, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sources.gz is preferred, but it is missing on the mirror
+	os.Remove(filepath.Join(baseUrl, "dists", "test", "main", "source", "Sources.gz"))
+
+	if si, err := r.Component("main").SourceIndex(context.Background()); err != nil || len(si.Packages) != 1 {
+		t.Fatal("uncompressed index must be used as a fallback", err)
+	}
+}
+
+func TestIndexAcquireByHash(t *testing.T) {
+	sources := gzipped(testSources)
+	baseUrl := localRepo(t, map[string][]byte{
+		"main/source/Sources.gz": sources,
+	})
+
+	releasePath := filepath.Join(baseUrl, "dists", "test", "Release")
+	release, _ := os.ReadFile(releasePath)
+	os.WriteFile(releasePath, append([]byte("Acquire-By-Hash: yes\n"), release...), 0o644)
+
+	r, err := repo.New(context.Background(), baseUrl, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.AcquireByHash {
+		t.Fatal("Acquire-By-Hash must be set")
+	}
+
+	// mirror is in the middle of sync: canonical file is already updated, but the one in by-hash matches Release
+	sourceDir := filepath.Join(baseUrl, "dists", "test", "main", "source")
+	os.MkdirAll(filepath.Join(sourceDir, "by-hash", "SHA256"), 0o755)
+	os.WriteFile(filepath.Join(sourceDir, "by-hash", "SHA256", fmt.Sprintf("%x", sha256.Sum256(sources))), sources, 0o644)
+	os.WriteFile(filepath.Join(sourceDir, "Sources.gz"), gzipped(testSources+"\nPackage: other\n"), 0o644)
+
+	if _, err := r.Component("main").SourceIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewWithFetcher(t *testing.T) {
+	sources := gzipped(testSources)
+	release := fmt.Sprintf("%sSHA256:\n %x %d main/source/Sources.gz\n", testRelease, sha256.Sum256(sources), len(sources))
+
+	r, err := repo.New(context.Background(), "mem://debian", "test", repo.WithFetcher(fetch.Memory{
+		"mem://debian/dists/test/Release":                []byte(release),
+		"mem://debian/dists/test/main/source/Sources.gz": sources,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Component("main").SourceIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := r.Component("main").SourceIndex(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled context must be respected, got %v", err)
+	}
+}
+
+func TestNewWithCacheDir(t *testing.T) {
+	baseUrl := localRepo(t, map[string][]byte{
+		"main/source/Sources.gz": gzipped(testSources),
+	})
+	cacheDir := t.TempDir()
+
+	for range 2 {
+		r, err := repo.New(context.Background(), baseUrl, "test", repo.WithCacheDir(cacheDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := r.Component("main").SourceIndex(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		// cached copy is used, even though the origin has gone
+		os.RemoveAll(filepath.Join(baseUrl, "dists", "test", "main"))
+	}
+}
+
+// fails to fetch urls with the suffix
+type failingFetcher struct {
+	fetch.Fetcher
+	suffix string
+}
+
+func (f failingFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	if strings.HasSuffix(url, f.suffix) {
+		return nil, errors.New("connection reset")
+	}
+	return f.Fetcher.Fetch(ctx, url)
+}
+
+func TestNewInReleaseError(t *testing.T) {
+	baseUrl, keyringPath := signedRepo(t, "Release.gpg")
+
+	_, err := repo.New(context.Background(), baseUrl, "test", repo.WithKeyring(keyringPath),
+		repo.WithFetcher(failingFetcher{fetch.Default(), "/InRelease"}))
+	if err == nil || err.Error() != "connection reset" {
+		t.Fatalf("only missing InRelease may fall back to Release.gpg, got %v", err)
+	}
+}
+
+func TestIndexChecksumMD5(t *testing.T) {
+	baseUrl := t.TempDir()
+	dist := filepath.Join(baseUrl, "dists", "test")
+	os.MkdirAll(filepath.Join(dist, "main", "source"), 0o755)
+
+	sources := gzipped(testSources)
+	os.WriteFile(filepath.Join(dist, "main", "source", "Sources.gz"), sources, 0o644)
+
+	release := testRelease + fmt.Sprintf("MD5Sum:\n %x %d main/source/Sources.gz\n", md5.Sum(sources), len(sources))
+	os.WriteFile(filepath.Join(dist, "Release"), []byte(release), 0o644)
+
+	r, err := repo.New(context.Background(), baseUrl, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if si, err := r.Component("main").SourceIndex(context.Background()); err != nil || len(si.Packages) != 1 {
+		t.Fatalf("index listed in MD5Sum only must be read, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dist, "main", "source", "Sources.gz"), gzipped(strings.Replace(testSources, "2.10-3", "2.10-4", 1)), 0o644)
+
+	var checksumErr *repo.ChecksumError
+	if _, err := r.Component("main").SourceIndex(context.Background()); !errors.As(err, &checksumErr) || checksumErr.Algorithm != "MD5Sum" {
+		t.Fatalf("MD5Sum mismatch must be reported, got %v", err)
+	}
+}
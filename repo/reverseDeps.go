@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/aol-nnov/debian/fields"
+)
+
+// Relation is a set of dependency fields to follow in reverse dependency lookups
+type Relation int
+
+const (
+	RelationDepends Relation = 1 << iota
+	RelationPreDepends
+	RelationRecommends
+	RelationSuggests
+	RelationBuildDepends
+	RelationBuildDependsArch
+	RelationBuildDependsIndep
+
+	// relations, which must be satisfied for a binary package to be installed
+	RelationStrong = RelationDepends | RelationPreDepends
+
+	RelationBinary = RelationStrong | RelationRecommends | RelationSuggests
+	RelationSource = RelationBuildDepends | RelationBuildDependsArch | RelationBuildDependsIndep
+)
+
+var relationNames = []string{
+	"Depends", "Pre-Depends", "Recommends", "Suggests", "Build-Depends", "Build-Depends-Arch", "Build-Depends-Indep",
+}
+
+// String returns field names of the set, i.e. "Depends|Pre-Depends"
+func (r Relation) String() string {
+	var res []string
+	for idx, name := range relationNames {
+		if r&(1<<idx) != 0 {
+			res = append(res, name)
+		}
+	}
+	return strings.Join(res, "|")
+}
+
+/*
+ReverseOptions narrow reverse dependency lookups down.
+
+Relations defaults to [RelationStrong] for binary packages and to [RelationSource] for source packages. MaxDepth
+limits transitive lookups: zero (the default) or 1 means direct reverse dependencies only, negative means no limit.
+*/
+type ReverseOptions struct {
+	Relations Relation
+	MaxDepth  int
+}
+
+// ReverseDependency is a package, which depends on another one
+type ReverseDependency struct {
+	Name         string
+	Version      fields.Version
+	Architecture fields.Architecture // empty for source packages
+
+	Relation   Relation
+	Dependency fields.Dependency // dependency group as declared, with alternatives
+	On         string            // package it depends on, which is the looked up package for Depth 1
+	Depth      int
+}
+
+// dependency of an index item on a package name
+type reverseEdge struct {
+	item     int
+	relation Relation
+	dep      fields.Dependency
+}
+
+// reverse dependency graph walker, common for binary and source indices
+type reverseWalk struct {
+	opts ReverseOptions
+
+	edges    map[string][]reverseEdge   // dependencies by names of packages they refer to
+	names    func(name string) []string // names, dependencies on which are satisfied by the package name
+	next     func(item int) []string    // packages to continue the transitive lookup with
+	describe func(item int) (string, fields.Version, fields.Architecture)
+}
+
+// adds dependencies of item. Each alternative is an edge, so dependency on "a | b" is a reverse dependency of both
+func (w *reverseWalk) add(item int, relation Relation, deps fields.Dependencies) {
+	if w.opts.Relations&relation == 0 {
+		return
+	}
+
+	for _, dep := range deps {
+		for alt := &dep; alt != nil; alt = alt.Alt {
+			w.edges[alt.Name] = append(w.edges[alt.Name], reverseEdge{item, relation, dep})
+		}
+	}
+}
+
+// breadth first search, so every package is reported at the lowest depth it is reached at
+func (w *reverseWalk) walk(name string) []ReverseDependency {
+	var res []ReverseDependency
+
+	// the same dependency may refer to several names of a package, i.e. real and virtual one
+	type reportKey struct {
+		item     int
+		relation Relation
+		dep      string
+	}
+	reported := make(map[reportKey]bool)
+	depthOf := make(map[int]int)
+	visited := map[string]bool{name: true}
+	queue := []string{name}
+
+	for depth := 1; len(queue) > 0 && (w.opts.MaxDepth < 0 || depth <= max(w.opts.MaxDepth, 1)); depth++ {
+		var nextQueue []string
+
+		for _, on := range queue {
+			for _, provided := range w.names(on) {
+				for _, edge := range w.edges[provided] {
+					if found, seen := depthOf[edge.item]; seen && found < depth {
+						continue
+					}
+					depthOf[edge.item] = depth
+
+					text, _ := edge.dep.MarshalText()
+					key := reportKey{edge.item, edge.relation, string(text)}
+					if reported[key] {
+						continue
+					}
+					reported[key] = true
+
+					pkgName, version, arch := w.describe(edge.item)
+					res = append(res, ReverseDependency{pkgName, version, arch, edge.relation, edge.dep, on, depth})
+
+					for _, next := range w.next(edge.item) {
+						if !visited[next] {
+							visited[next] = true
+							nextQueue = append(nextQueue, next)
+						}
+					}
+				}
+			}
+		}
+
+		queue = nextQueue
+	}
+
+	slices.SortStableFunc(res, func(a, b ReverseDependency) int {
+		return cmp.Or(cmp.Compare(a.Depth, b.Depth), cmp.Compare(a.Name, b.Name))
+	})
+
+	return res
+}
+
+/*
+ReverseDepends returns binary packages, which depend on the binary package name either directly or through
+a virtual package it provides (in any version). Every alternative counts, so the dependency may still be satisfied
+by another package, see ReverseDependency.Dependency.
+
+With transitive lookup, reverse dependencies of the found packages are returned too.
+*/
+func (bi BinaryIndex) ReverseDepends(name string, opts ReverseOptions) []ReverseDependency {
+	if opts.Relations == 0 {
+		opts.Relations = RelationStrong
+	}
+
+	w := reverseWalk{
+		opts:  opts,
+		edges: make(map[string][]reverseEdge),
+		names: func(name string) []string {
+			res := []string{name}
+			for _, idx := range bi.byName[name] {
+				for _, provided := range bi.Items[idx].Provides {
+					if !slices.Contains(res, provided.Name) {
+						res = append(res, provided.Name)
+					}
+				}
+			}
+			return res
+		},
+		next: func(item int) []string { return []string{bi.Items[item].Name} },
+		describe: func(item int) (string, fields.Version, fields.Architecture) {
+			return bi.Items[item].Name, bi.Items[item].Version, bi.Items[item].Architecture
+		},
+	}
+
+	for idx, pkg := range bi.Items {
+		w.add(idx, RelationDepends, pkg.Depends)
+		w.add(idx, RelationPreDepends, pkg.PreDepends)
+		w.add(idx, RelationRecommends, pkg.Recommends)
+		w.add(idx, RelationSuggests, pkg.Suggests)
+	}
+
+	return w.walk(name)
+}
+
+/*
+ReverseBuildDepends returns source packages, which build depend on the binary package name. Virtual packages are not
+considered, as the source index does not list them.
+
+With transitive lookup, source packages build depending on binaries of the found ones are returned too, which is
+what has to be rebuilt after name changes.
+*/
+func (si SourceIndex) ReverseBuildDepends(name string, opts ReverseOptions) []ReverseDependency {
+	if opts.Relations == 0 {
+		opts.Relations = RelationSource
+	}
+
+	w := reverseWalk{
+		opts:  opts,
+		edges: make(map[string][]reverseEdge),
+		names: func(name string) []string { return []string{name} },
+		next:  func(item int) []string { return si.Packages[item].Binary },
+		describe: func(item int) (string, fields.Version, fields.Architecture) {
+			return si.Packages[item].Name, si.Packages[item].Version, fields.Architecture{}
+		},
+	}
+
+	for idx, pkg := range si.Packages {
+		w.add(idx, RelationBuildDepends, pkg.BuildDepends)
+		w.add(idx, RelationBuildDependsArch, pkg.BuildDependsArch)
+		w.add(idx, RelationBuildDependsIndep, pkg.BuildDependsIndep)
+	}
+
+	return w.walk(name)
+}
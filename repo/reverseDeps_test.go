@@ -0,0 +1,111 @@
+package repo_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/repo"
+)
+
+const reversePackages = `Package: libfoo1
+Version: 1.0-1
+Architecture: amd64
+Provides: libfoo-abi-1
+
+Package: libfoo-dev
+Version: 1.0-1
+Architecture: amd64
+Depends: libfoo1 (= 1.0-1)
+
+Package: bar
+Version: 2
+Architecture: amd64
+Depends: libfoo-abi-1 | libfoo2
+
+Package: baz
+Version: 3
+Architecture: all
+Recommends: bar
+Depends: bar
+
+Package: quux
+Version: 4
+Architecture: all
+Suggests: baz
+`
+
+func ExampleBinaryIndex_ReverseDepends() {
+	index, _ := repo.NewBinaryIndex(strings.NewReader(reversePackages), nil)
+
+	for _, rdep := range index.ReverseDepends("libfoo1", repo.ReverseOptions{Relations: repo.RelationBinary, MaxDepth: -1}) {
+		dep, _ := rdep.Dependency.MarshalText()
+		fmt.Println(rdep.Depth, rdep.Name, rdep.Relation, string(dep), "on", rdep.On)
+	}
+
+	// Output:
+	// 1 bar Depends libfoo-abi-1 | libfoo2 on libfoo1
+	// 1 libfoo-dev Depends libfoo1 (= 1.0-1) on libfoo1
+	// 2 baz Depends bar on bar
+	// 2 baz Recommends bar on bar
+	// 3 quux Suggests baz on baz
+}
+
+func TestReverseDependsDepth(t *testing.T) {
+	index, err := repo.NewBinaryIndex(strings.NewReader(reversePackages), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rdeps := index.ReverseDepends("libfoo1", repo.ReverseOptions{}); len(rdeps) != 2 {
+		t.Fatalf("expected direct strong reverse dependencies only, got %v", rdeps)
+	}
+
+	if rdeps := index.ReverseDepends("bar", repo.ReverseOptions{Relations: repo.RelationRecommends, MaxDepth: 5}); len(rdeps) != 1 || rdeps[0].Relation != repo.RelationRecommends {
+		t.Fatalf("unexpected reverse dependencies %v", rdeps)
+	}
+}
+
+func TestReverseBuildDepends(t *testing.T) {
+	const sources = `Package: foo
+Binary: libfoo1, libfoo-dev
+Version: 1.0-1
+Architecture: any
+
+Package: bar
+Binary: bar
+Version: 2
+Architecture: any
+Build-Depends: libfoo-dev
+
+Package: baz
+Binary: baz
+Version: 3
+Architecture: all
+Build-Depends-Indep: bar (>= 2)
+
+Package: unrelated
+Binary: unrelated
+Version: 1
+Architecture: all
+Build-Depends: debhelper-compat (= 13)
+`
+
+	index, err := repo.NewSourceIndex(strings.NewReader(sources), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res []string
+	for _, rdep := range index.ReverseBuildDepends("libfoo-dev", repo.ReverseOptions{MaxDepth: 2}) {
+		res = append(res, fmt.Sprintf("%d %s %s", rdep.Depth, rdep.Name, rdep.Relation))
+	}
+
+	if strings.Join(res, ", ") != "1 bar Build-Depends, 2 baz Build-Depends-Indep" {
+		t.Fatal(res)
+	}
+
+	if rdeps := index.ReverseBuildDepends("libfoo-dev", repo.ReverseOptions{Relations: repo.RelationBuildDepends, MaxDepth: 2}); len(rdeps) != 1 {
+		t.Fatalf("relation filter is not applied: %v", rdeps)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/aol-nnov/debian/deb822"
 	"github.com/aol-nnov/debian/fields"
 	"github.com/aol-nnov/debian/internal/universalreader"
-	"golang.org/x/exp/maps"
 )
 
 type SourceIndex struct {
@@ -16,7 +15,7 @@ type SourceIndex struct {
 
 	// []int for storing indices to multiple versions of single package
 	byName       map[string][]int
-	byBinaryName map[string]int
+	byBinaryName map[string][]int
 }
 
 type SourceIndexItem struct {
@@ -28,6 +27,10 @@ type SourceIndexItem struct {
 	BuildDependsArch  fields.Dependencies `deb822:"Build-Depends-Arch" delim:"," strip:" "`
 	BuildDependsIndep fields.Dependencies `deb822:"Build-Depends-Indep" delim:"," strip:" "`
 
+	BuildConflicts      fields.Dependencies `deb822:"Build-Conflicts" delim:"," strip:" "`
+	BuildConflictsArch  fields.Dependencies `deb822:"Build-Conflicts-Arch" delim:"," strip:" "`
+	BuildConflictsIndep fields.Dependencies `deb822:"Build-Conflicts-Indep" delim:"," strip:" "`
+
 	Architecture []fields.Architecture `required:"true" delim:" " strip:" "`
 	// StandardsVersion string `deb822:"Standards-Version"`
 	// Format  string
@@ -42,18 +45,13 @@ func NewSourceIndex(reader io.Reader, inErr error) (*SourceIndex, error) {
 	if inErr != nil {
 		return nil, inErr
 	}
-	var res SourceIndex
-
-	err := deb822.NewDecoder(reader).Decode(&res.Packages)
 	defer universalreader.MaybeClose(reader)
 
-	if err != nil {
-		return nil, err
+	res := SourceIndex{
+		byName:       make(map[string][]int),
+		byBinaryName: make(map[string][]int),
 	}
 
-	res.byName = make(map[string][]int, len(res.Packages))
-	res.byBinaryName = make(map[string]int, len(res.Packages))
-
 	// for idx, pkg := range res.Packages {
 	// 	// let's cache index of deb-src with max version
 	// 	if cachedIdx, found := res.byName[pkg.Name]; found {
@@ -70,12 +68,20 @@ func NewSourceIndex(reader io.Reader, inErr error) (*SourceIndex, error) {
 	// 	// }
 	// }
 
-	for idx, pkg := range res.Packages {
+	// stanzas are decoded one by one, so only the resulting items are kept in memory
+	for pkg, err := range deb822.Iter[SourceIndexItem](deb822.NewDecoder(reader)) {
+		if err != nil {
+			return nil, err
+		}
+
+		idx := len(res.Packages)
 		res.byName[pkg.Name] = append(res.byName[pkg.Name], idx)
 
 		for _, bin := range pkg.Binary {
-			res.byBinaryName[bin] = idx
+			res.byBinaryName[bin] = append(res.byBinaryName[bin], idx)
 		}
+
+		res.Packages = append(res.Packages, pkg)
 	}
 
 	// for name, i := range res.byName {
@@ -85,92 +91,88 @@ func NewSourceIndex(reader io.Reader, inErr error) (*SourceIndex, error) {
 	return &res, nil
 }
 
+func (si SourceIndex) items(indices []int) []SourceIndexItem {
+	res := make([]SourceIndexItem, 0, len(indices))
+	for _, idx := range indices {
+		res = append(res, si.Packages[idx])
+	}
+	return res
+}
+
 func (si SourceIndex) FindByName(name string) ([]SourceIndexItem, bool) {
 
 	if packages, found := si.byName[name]; found {
-		res := make([]SourceIndexItem, 0, len(packages))
-		for _, idx := range packages {
-			res = append(res, si.Packages[idx])
-		}
 		// return si.Packages[idx], true
-		return res, true
+		return si.items(packages), true
 	}
 
 	return nil, false
 }
 
-func (si SourceIndex) FindByBinaryName(name string) (*SourceIndexItem, bool) {
-	if idx, found := si.byBinaryName[name]; found {
-		return &si.Packages[idx], true
+// FindByBinaryName returns all source packages (and all their versions), which list binary package name
+func (si SourceIndex) FindByBinaryName(name string) ([]SourceIndexItem, bool) {
+	if packages, found := si.byBinaryName[name]; found {
+		return si.items(packages), true
 	}
 
 	return nil, false
 }
 
-func (si SourceIndex) FindByConstraint(dep fields.Dependency) (*SourceIndexItem, bool) {
-
-	return nil, false
-}
+/*
+FindByConstraint returns source packages, which build a binary package satisfying dep. Source version is matched
+against version constraint of dep.
+
+Matches of the first alternative come first, then the ones of dep.Alt and so on. Matches of each alternative are
+ordered by version, highest first. Architecture and profile restrictions are not considered.
+*/
+func (si SourceIndex) FindByConstraint(dep fields.Dependency) ([]SourceIndexItem, bool) {
+	var res []SourceIndexItem
+	seen := make(map[int]bool)
+
+	for alt := &dep; alt != nil; alt = alt.Alt {
+		var matches []int
+		for _, idx := range si.byBinaryName[alt.Name] {
+			if seen[idx] {
+				continue
+			}
 
-// Kahn toposort algorithm
-// https://github.com/amwolff/gorder/blob/master/gorder.go
-//
-// alternatives:
-// depth first traversal
-// https://github.com/ninedraft/tsort/blob/master/sort.go (colour sort)
-// https://github.com/paultag/go-topsort/blob/master/topsort.go
-// TODO: найти алгоритм, разделяющий граф на сортированные поддеревья - возможность распараллелить задачу сборки
-func (si SourceIndex) BuildOrder() (topologicalOrder []string, missing []string, err error) {
-	indegree := make(map[string]int)
-	pkgDeps := make(map[string][]string)
-	missingQueue := make(map[string]bool, len(si.Packages))
-	var queue []string
-
-	for pkgName := range si.byName {
-
-		pkg := si.Packages[si.byName[pkgName][0]]
-
-		allDepends := slices.Concat(pkg.BuildDepends, pkg.BuildDependsArch, pkg.BuildDependsIndep)
-		for _, binDependency := range allDepends {
-			if srcDep, found := si.FindByBinaryName(binDependency.Name); found {
-				pkgDeps[pkgName] = append(pkgDeps[pkgName], srcDep.Name)
-				indegree[srcDep.Name]++
-			} else {
-				missingQueue[binDependency.Name] = true
+			if alt.VersionConstraint == nil || alt.VersionConstraint.SatisfiedBy(si.Packages[idx].Version) {
+				matches = append(matches, idx)
+				seen[idx] = true
 			}
 		}
-	}
 
-	for pkgName := range si.byName {
-		if _, ok := indegree[pkgName]; !ok {
-			queue = append(queue, pkgName)
-		}
+		slices.SortStableFunc(matches, func(a, b int) int {
+			if res := si.Packages[b].Version.Compare(si.Packages[a].Version); res != fields.VersionCompareResultNonComparable {
+				return int(res)
+			}
+			return 0
+		})
+
+		res = append(res, si.items(matches)...)
 	}
 
-	cnt := 0
-	for len(queue) > 0 {
-		pkgName := queue[len(queue)-1]
-		queue = queue[:(len(queue) - 1)] // pops last element from queue
+	return res, len(res) > 0
+}
 
-		topologicalOrder = append(topologicalOrder, pkgName)
+/*
+BuildOrder returns source packages ordered so that build dependencies come first, along with the missing binary
+packages. It is a flattened [SourceIndex.BuildPlan], which is preferred, as it tells what can be built in parallel.
 
-		for _, dep := range pkgDeps[pkgName] {
-			indegree[dep]--
-			if indegree[dep] == 0 {
-				queue = append(queue, dep)
-			}
-		}
-		cnt++
-	}
+Cycles are reported as an error, the order is returned anyway.
+*/
+func (si SourceIndex) BuildOrder(opts BuildOptions) (topologicalOrder []string, missing []string, err error) {
+	plan := si.BuildPlan(opts)
 
-	missing = maps.Keys(missingQueue)
+	for _, wave := range plan.Waves {
+		topologicalOrder = append(topologicalOrder, wave...)
+	}
 
-	if cnt != len(si.byName) {
-		err = fmt.Errorf("cyclic graph: visited %d, total %d", cnt, len(si.byName))
+	if len(plan.Cycles) > 0 {
+		err = fmt.Errorf("cyclic graph: %v", plan.Cycles)
 	}
 
-	slices.Reverse(topologicalOrder)
-	return
+	return topologicalOrder, plan.Missing, err
 }
 
 // func (si SourceIndex) BuildOrderIndices() (topologicalOrder []int, missing []string, err error) {
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aol-nnov/debian/fields"
 	"github.com/aol-nnov/debian/internal/universalreader"
 	"github.com/aol-nnov/debian/repo"
 	"golang.org/x/exp/slices"
@@ -151,7 +153,7 @@ func TestTopoSort(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	topo, _, err := si.BuildOrder()
+	topo, _, err := si.BuildOrder(repo.BuildOptions{})
 
 	if err != nil {
 		t.Fatal(err)
@@ -161,3 +163,185 @@ func TestTopoSort(t *testing.T) {
 		fmt.Println(p)
 	}
 }
+
+const multiVersionSources = `Package: gcc-12
+Binary: gcc-12, cpp-12
+Version: 12.2.0-14
+Architecture: any
+
+Package: gcc-12
+Binary: gcc-12, cpp-12
+Version: 12.3.0-1
+Architecture: any
+
+Package: gcc-13
+Binary: gcc-13, cpp-13
+Version: 13.2.0-1
+Architecture: any
+
+Package: gcc-12-cross
+Binary: gcc-12
+Version: 12.1.0-1
+Architecture: any
+`
+
+func TestFindByConstraint(t *testing.T) {
+	si, err := repo.NewSourceIndex(strings.NewReader(multiVersionSources), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if packages, _ := si.FindByBinaryName("gcc-12"); len(packages) != 3 {
+		t.Fatalf("every source listing gcc-12 expected, got %v", packages)
+	}
+
+	var dep fields.Dependency
+	dep.UnmarshalText([]byte("gcc-12 (>= 12.2) | gcc-13"))
+
+	packages, found := si.FindByConstraint(dep)
+	if !found {
+		t.Fatal("gcc-12 must be found")
+	}
+
+	if fmt.Sprint(packages) != "[gcc-12 12.3.0-1 gcc-12 12.2.0-14 gcc-13 13.2.0-1]" {
+		t.Fatalf("unexpected result %v", packages)
+	}
+
+	var older fields.Dependency
+	older.UnmarshalText([]byte("cpp-12 (<< 12.2)"))
+	if _, found := si.FindByConstraint(older); found {
+		t.Fatal("cpp-12 (<< 12.2) must not be found")
+	}
+}
+
+const cyclicSources = `Package: base
+Binary: libbase
+Version: 1.0-1
+Architecture: any
+
+Package: mid
+Binary: libmid
+Version: 1.0-1
+Architecture: any
+
+Package: mid
+Binary: libmid
+Version: 2.0-1
+Architecture: any
+Build-Depends: libbase, debhelper-compat (= 13)
+
+Package: a
+Binary: liba
+Version: 1.0-1
+Architecture: any
+Build-Depends: libb, libmid (>= 2.0)
+
+Package: b
+Binary: libb
+Version: 1.0-1
+Architecture: any
+Build-Depends-Indep: liba
+
+Package: top
+Binary: top
+Version: 1.0-1
+Architecture: any
+Build-Depends: liba, libbase
+`
+
+func TestBuildPlan(t *testing.T) {
+	si, err := repo.NewSourceIndex(strings.NewReader(cyclicSources), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := si.BuildPlan(repo.BuildOptions{})
+
+	if fmt.Sprint(plan.Waves) != "[[base] [mid] [a b] [top]]" {
+		t.Fatalf("unexpected waves %v", plan.Waves)
+	}
+
+	if len(plan.Cycles) != 1 || fmt.Sprint(plan.Cycles[0].Sources) != "[a b]" || len(plan.Cycles[0].Edges) != 2 {
+		t.Fatalf("unexpected cycles %v", plan.Cycles)
+	}
+
+	if fmt.Sprint(plan.Missing) != "[debhelper-compat]" {
+		t.Fatalf("unexpected missing packages %v", plan.Missing)
+	}
+
+	order, _, err := si.BuildOrder(repo.BuildOptions{})
+	if err == nil || fmt.Sprint(order) != "[base mid a b top]" {
+		t.Fatalf("unexpected build order %v %v", order, err)
+	}
+}
+
+const bootstrapSources = `Package: gcc
+Binary: gcc
+Version: 1.0-1
+Architecture: any
+
+Package: libc
+Binary: libc-dev
+Version: 1.0-1
+Architecture: any
+
+Package: a
+Binary: liba
+Version: 1.0-1
+Architecture: any
+Build-Depends: libb <!stage1>, libc-dev [!riscv64], gcc:native
+
+Package: b
+Binary: libb
+Version: 1.0-1
+Architecture: any
+Build-Depends: liba, check-tool <!nocheck>
+Build-Depends-Indep: libdoc
+
+Package: check-tool
+Binary: check-tool
+Version: 1.0-1
+Architecture: any
+Build-Depends: libb
+
+Package: doc
+Binary: libdoc
+Version: 1.0-1
+Architecture: all
+Build-Depends: libb
+`
+
+func TestBuildPlanBootstrap(t *testing.T) {
+	si, err := repo.NewSourceIndex(strings.NewReader(bootstrapSources), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan := si.BuildPlan(repo.BuildOptions{}); len(plan.Cycles) != 1 || len(plan.Cycles[0].Sources) != 4 {
+		t.Fatalf("a cycle of 4 packages expected, got %v", plan.Cycles)
+	}
+
+	plan := si.BuildPlan(repo.BuildOptions{
+		BuildArch: fields.MakeArch("amd64"),
+		HostArch:  fields.MakeArch("riscv64"),
+		Profiles:  []string{"stage1", "nocheck"},
+		Mode:      repo.BuildArchOnly,
+	})
+
+	if len(plan.Cycles) != 0 || fmt.Sprint(plan.Waves) != "[[a gcc libc] [b] [check-tool doc]]" {
+		t.Fatalf("unexpected bootstrap plan %v %v", plan.Waves, plan.Cycles)
+	}
+}
+
+func TestBuildOptionsRelations(t *testing.T) {
+	var alternatives, check fields.Dependency
+	alternatives.UnmarshalText([]byte("foo [amd64] | bar [i386]"))
+	check.UnmarshalText([]byte("check-tool <!nocheck>"))
+
+	opts := repo.BuildOptions{HostArch: fields.MakeArch("i386"), Profiles: []string{"nocheck"}}
+	deps := opts.Relations(fields.Dependencies{alternatives, check}, nil, nil)
+
+	if len(deps) != 1 || deps[0].Name != "bar" || deps[0].Alt != nil {
+		t.Fatalf("expected only bar, got %v", deps)
+	}
+}
@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aol-nnov/debian/fields"
+)
+
+// BinNMU is a binary-only rebuild of a source package
+type BinNMU struct {
+	Source      string
+	Version     fields.Version // source version
+	NextVersion fields.Version // version of rebuilt binaries, i.e. 1.0-1+b2
+	Binaries    []string       // binary packages, which depend on the old library
+}
+
+func (b BinNMU) String() string {
+	return fmt.Sprintf("%s_%s", b.Source, b.NextVersion)
+}
+
+/*
+Transition is a result of [PlanTransition].
+
+Waves order binNMUs, so sources build depending on other affected sources are rebuilt after them. Sources, which ship
+`Architecture: all` packages depending on the old library, are listed in Sourceful: binNMU does not rebuild such
+packages, so they need a sourceful upload.
+*/
+type Transition struct {
+	Old, New string
+
+	BinNMUs   []BinNMU // sorted by source name
+	Waves     [][]string
+	Cycles    []Cycle
+	Sourceful []string
+}
+
+/*
+PlanTransition plans a library transition from binary package oldName to newName (i.e. after SONAME change).
+
+Affected are source packages, which build binaries depending (Depends or Pre-Depends) on oldName, except for the
+library source itself. Each of them gets the next +bN version after the highest binNMU found in bi. Waves are computed
+with opts like [SourceIndex.BuildPlan] does, only dependencies between affected sources count.
+*/
+func PlanTransition(si *SourceIndex, bi *BinaryIndex, oldName, newName string, opts BuildOptions) (*Transition, error) {
+	if _, found := bi.FindByName(oldName); !found {
+		return nil, fmt.Errorf("binary package %s is not in the index", oldName)
+	}
+
+	library := make(map[string]bool)
+	for _, name := range []string{oldName, newName} {
+		pkgs, _ := bi.FindByName(name)
+		for _, pkg := range pkgs {
+			library[pkg.Source.Name] = true
+		}
+
+		srcs, _ := si.FindByBinaryName(name)
+		for _, src := range srcs {
+			library[src.Name] = true
+		}
+	}
+
+	if _, found := si.FindByBinaryName(newName); !found {
+		if _, found := bi.FindByName(newName); !found {
+			return nil, fmt.Errorf("binary package %s is neither in the index, nor built by any source package", newName)
+		}
+	}
+
+	res := Transition{Old: oldName, New: newName}
+
+	affected := make(map[string]*BinNMU)
+	sourceful := make(map[string]bool)
+	for _, rdep := range bi.ReverseDepends(oldName, ReverseOptions{Relations: RelationStrong}) {
+		pkg, _ := bi.Find(rdep.Name, rdep.Version, rdep.Architecture)
+		if library[pkg.Source.Name] {
+			continue
+		}
+
+		if pkg.Architecture == fields.MakeArch("all") {
+			sourceful[pkg.Source.Name] = true
+			continue
+		}
+
+		nmu, found := affected[pkg.Source.Name]
+		if !found {
+			nmu = &BinNMU{Source: pkg.Source.Name, Version: pkg.SourceVersion()}
+			affected[pkg.Source.Name] = nmu
+		} else if nmu.Version.Less(pkg.SourceVersion()) {
+			nmu.Version = pkg.SourceVersion()
+		}
+
+		if !slices.Contains(nmu.Binaries, pkg.Name) {
+			nmu.Binaries = append(nmu.Binaries, pkg.Name)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(affected)) {
+		nmu := affected[name]
+		nmu.NextVersion = bi.nextBinNMU(nmu.Source, nmu.Version)
+		slices.Sort(nmu.Binaries)
+		res.BinNMUs = append(res.BinNMUs, *nmu)
+	}
+	res.Sourceful = slices.Sorted(maps.Keys(sourceful))
+
+	// only dependencies between affected sources matter
+	allEdges, _ := si.buildGraph(opts)
+	edges := make(map[string][]BuildEdge, len(affected))
+	for name := range affected {
+		for _, edge := range allEdges[name] {
+			if _, found := affected[edge.To]; found {
+				edges[name] = append(edges[name], edge)
+			}
+		}
+	}
+
+	plan := planWaves(slices.Collect(maps.Keys(affected)), edges)
+	res.Waves, res.Cycles = plan.Waves, plan.Cycles
+
+	return &res, nil
+}
+
+// returns version for the next binNMU of the source package
+func (bi BinaryIndex) nextBinNMU(source string, version fields.Version) fields.Version {
+	last := 0
+
+	binaries, _ := bi.FindBySource(source, version)
+	for _, pkg := range binaries {
+		if pkg.Version.IsMod() != fields.VersionModNmuBinary {
+			continue
+		}
+
+		mod := pkg.Version.Modificators[len(pkg.Version.Modificators)-1]
+		if n, err := strconv.Atoi(strings.TrimPrefix(mod, "+b")); err == nil {
+			last = max(last, n)
+		}
+	}
+
+	res := version
+	res.Modificators = slices.Clone(version.Modificators)
+	if last > 0 {
+		res.AddMod(fmt.Sprintf("+b%d", last))
+	}
+	res.BinaryNmu()
+
+	return res
+}
@@ -0,0 +1,103 @@
+package repo_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/repo"
+)
+
+const transitionPackages = `Package: libfoo1
+Source: foo
+Version: 1.0-1
+Architecture: amd64
+
+Package: libfoo-dev
+Source: foo
+Version: 1.0-1
+Architecture: amd64
+Depends: libfoo1 (= 1.0-1)
+
+Package: libfoo2
+Source: foo
+Version: 2.0-1
+Architecture: amd64
+
+Package: libbar1
+Source: bar (3.0-2)
+Version: 3.0-2+b1
+Architecture: amd64
+Depends: libfoo1 (>= 1.0)
+
+Package: bar-tools
+Source: bar (3.0-2)
+Version: 3.0-2+b1
+Architecture: amd64
+Depends: libbar1, libfoo1
+
+Package: app
+Version: 5
+Architecture: amd64
+Depends: libbar1, libfoo1
+
+Package: app-data
+Source: app
+Version: 5
+Architecture: all
+Depends: libfoo1
+`
+
+const transitionSources = `Package: foo
+Binary: libfoo2, libfoo-dev
+Version: 2.0-1
+Architecture: any
+
+Package: bar
+Binary: libbar1, libbar-dev, bar-tools
+Version: 3.0-2
+Architecture: any
+Build-Depends: libfoo-dev
+
+Package: app
+Binary: app, app-data
+Version: 5
+Architecture: any all
+Build-Depends: libfoo-dev, libbar-dev
+`
+
+func ExamplePlanTransition() {
+	binaries, _ := repo.NewBinaryIndex(strings.NewReader(transitionPackages), nil)
+	sources, _ := repo.NewSourceIndex(strings.NewReader(transitionSources), nil)
+
+	transition, err := repo.PlanTransition(sources, binaries, "libfoo1", "libfoo2", repo.BuildOptions{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, nmu := range transition.BinNMUs {
+		fmt.Println(nmu, nmu.Binaries)
+	}
+	fmt.Println(transition.Waves)
+	fmt.Println(transition.Sourceful)
+
+	// Output:
+	// app_5+b1 [app]
+	// bar_3.0-2+b2 [bar-tools libbar1]
+	// [[bar] [app]]
+	// [app]
+}
+
+func TestPlanTransitionMissing(t *testing.T) {
+	binaries, _ := repo.NewBinaryIndex(strings.NewReader(transitionPackages), nil)
+	sources, _ := repo.NewSourceIndex(strings.NewReader(transitionSources), nil)
+
+	if _, err := repo.PlanTransition(sources, binaries, "libfoo0", "libfoo2", repo.BuildOptions{}); err == nil {
+		t.Fatal("unknown old library must fail")
+	}
+
+	if _, err := repo.PlanTransition(sources, binaries, "libfoo1", "libfoo3", repo.BuildOptions{}); err == nil {
+		t.Fatal("unknown new library must fail")
+	}
+}
@@ -0,0 +1,121 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/pkg"
+	"github.com/aol-nnov/debian/repo"
+)
+
+// ErrUndetermined is returned, if search limit was reached before the answer was found
+var ErrUndetermined = errors.New("search limit reached")
+
+// UnsatisfiableError is returned, if build dependencies can not be installed
+type UnsatisfiableError struct {
+	Source      string
+	Explanation []string
+}
+
+func (e *UnsatisfiableError) Error() string {
+	return fmt.Sprintf("%s: build dependencies are not satisfiable:\n  %s", e.Source, strings.Join(e.Explanation, "\n  "))
+}
+
+// Solution is a set of binary packages to be installed to build a source package
+type Solution struct {
+	Packages []repo.BinaryIndexItem // sorted by name
+}
+
+// Install returns packages in name=version:arch form, suitable for apt-get install
+func (s Solution) Install() []string {
+	res := make([]string, 0, len(s.Packages))
+	for _, pkg := range s.Packages {
+		res = append(res, fmt.Sprintf("%s=%s:%s", pkg.Name, pkg.Version, pkg.Architecture))
+	}
+	return res
+}
+
+/*
+BuildDepsSolver computes installation sets for build dependencies of source packages, much like sbuild resolver does.
+
+The set includes essential packages of the build architecture and, if the universe has it, build-essential:native.
+Build-Conflicts are honoured. Packages of the host architecture must be a part of the universe to cross build.
+
+Dependencies, which can not be installed, are reported as [*UnsatisfiableError]. If MaxSteps is reached before the
+answer is found, [ErrUndetermined] is returned instead.
+*/
+type BuildDepsSolver struct {
+	Universe *Universe
+
+	// search gives up after trying that many candidates, [DefaultMaxSteps] if zero
+	MaxSteps int
+}
+
+// NewBuildDepsSolver returns solver over packages of u
+func NewBuildDepsSolver(u *Universe) *BuildDepsSolver {
+	return &BuildDepsSolver{Universe: u}
+}
+
+// SolveSource computes installation set to build source package of an index
+func (b *BuildDepsSolver) SolveSource(src repo.SourceIndexItem, opts repo.BuildOptions) (*Solution, error) {
+	return b.solve(
+		fmt.Sprintf("%s=%s", src.Name, src.Version),
+		opts,
+		[3]fields.Dependencies{src.BuildDepends, src.BuildDependsArch, src.BuildDependsIndep},
+		[3]fields.Dependencies{src.BuildConflicts, src.BuildConflictsArch, src.BuildConflictsIndep},
+	)
+}
+
+// SolveControl computes installation set to build source package described by debian/control
+func (b *BuildDepsSolver) SolveControl(control pkg.Control, opts repo.BuildOptions) (*Solution, error) {
+	src := control.DebSrc
+	return b.solve(
+		src.Name,
+		opts,
+		[3]fields.Dependencies{src.BuildDepends, src.BuildDependsArch, src.BuildDependsIndep},
+		[3]fields.Dependencies{src.BuildConflicts, src.BuildConflictsArch, src.BuildConflictsIndep},
+	)
+}
+
+// BuildArch must be set in opts, HostArch defaults to BuildArch
+func (b *BuildDepsSolver) solve(source string, opts repo.BuildOptions, depends, conflicts [3]fields.Dependencies) (*Solution, error) {
+	noArch := fields.Architecture{}
+	if opts.BuildArch == noArch {
+		return nil, errors.New("build architecture is not set")
+	}
+	if opts.HostArch == noArch {
+		opts.HostArch = opts.BuildArch
+	}
+
+	s := newSearch(b.Universe, opts.BuildArch, b.MaxSteps)
+
+	for _, dep := range opts.Relations(conflicts[0], conflicts[1], conflicts[2]) {
+		s.conflicts = append(s.conflicts, goal{dep: dep, arch: opts.HostArch, from: -1, root: source, relation: "build conflicts with"})
+	}
+
+	goals := s.essentialGoals()
+	if _, found := b.Universe.byName["build-essential"]; found {
+		goals = append(goals, goal{
+			dep:      fields.Dependency{Name: "build-essential", ArchQualifier: "native"},
+			arch:     opts.BuildArch,
+			from:     -1,
+			root:     source,
+			relation: "build-depends on",
+		})
+	}
+
+	for _, dep := range opts.Relations(depends[0], depends[1], depends[2]) {
+		goals = append(goals, goal{dep: dep, arch: opts.HostArch, from: -1, root: source, relation: "build-depends on"})
+	}
+
+	if f := s.solve(goals); f != nil {
+		if f.exhausted {
+			return nil, fmt.Errorf("%s: build dependencies: %w, %s", source, ErrUndetermined, strings.Join(f.explanation, ", "))
+		}
+		return nil, &UnsatisfiableError{Source: source, Explanation: f.explanation}
+	}
+
+	return &Solution{Packages: s.installation()}, nil
+}
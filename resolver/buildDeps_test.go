@@ -0,0 +1,148 @@
+package resolver_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/pkg"
+	"github.com/aol-nnov/debian/repo"
+	"github.com/aol-nnov/debian/resolver"
+)
+
+const buildPackages = `Package: base-files
+Version: 13
+Architecture: amd64
+Essential: yes
+
+Package: build-essential
+Version: 12.10
+Architecture: amd64
+Depends: gcc-12
+
+Package: gcc-12
+Version: 12.2.0-14
+Architecture: amd64
+
+Package: debhelper
+Version: 13.11.4
+Architecture: all
+Provides: debhelper-compat (= 13)
+
+Package: libfoo-dev
+Version: 2.0-1
+Architecture: amd64
+Depends: libfoo2 (= 2.0-1)
+
+Package: libfoo2
+Version: 2.0-1
+Architecture: amd64
+
+Package: libfoo-dev
+Version: 1.0-1
+Architecture: amd64
+
+Package: python3
+Version: 3.11.2-1
+Architecture: amd64
+Multi-Arch: allowed
+
+Package: libbad-dev
+Version: 1
+Architecture: amd64
+Provides: libfoo-dev (= 2.0-1)
+`
+
+const sources = `Package: hello
+Binary: hello
+Version: 2.10-3
+Architecture: any
+Build-Depends: debhelper-compat (= 13), libbad-dev | libfoo-dev (>= 1.0), python3:any, libwin-dev [windows-any],
+ libcheck-dev <!nocheck>
+Build-Conflicts: libbad-dev
+`
+
+func ExampleBuildDepsSolver_SolveSource() {
+	binaries, _ := repo.NewBinaryIndex(strings.NewReader(buildPackages), nil)
+	sources, _ := repo.NewSourceIndex(strings.NewReader(sources), nil)
+
+	solver := resolver.NewBuildDepsSolver(resolver.NewUniverse(binaries))
+
+	solution, err := solver.SolveSource(sources.Packages[0], repo.BuildOptions{
+		BuildArch: fields.MakeArch("amd64"),
+		Profiles:  []string{"nocheck"},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, pkg := range solution.Install() {
+		fmt.Println(pkg)
+	}
+
+	// Output:
+	// base-files=13:amd64
+	// build-essential=12.10:amd64
+	// debhelper=13.11.4:all
+	// gcc-12=12.2.0-14:amd64
+	// libfoo-dev=2.0-1:amd64
+	// libfoo2=2.0-1:amd64
+	// python3=3.11.2-1:amd64
+}
+
+func TestSolveUnsatisfiable(t *testing.T) {
+	var dep fields.Dependency
+	dep.UnmarshalText([]byte("debhelper-compat (= 14)"))
+
+	control := pkg.Control{DebSrc: pkg.SourcePackage{Name: "hello", BuildDepends: fields.Dependencies{dep}}}
+
+	solver := resolver.NewBuildDepsSolver(universe(t, buildPackages))
+	_, err := solver.SolveControl(control, repo.BuildOptions{BuildArch: fields.MakeArch("amd64")})
+
+	var ue *resolver.UnsatisfiableError
+	if !errors.As(err, &ue) {
+		t.Fatalf("expected UnsatisfiableError, got %v", err)
+	}
+
+	if strings.Join(ue.Explanation, "\n") != "hello build-depends on debhelper-compat (= 14)\nno package satisfies debhelper-compat (= 14)" {
+		t.Fatal(err)
+	}
+}
+
+func TestSolveBuildConflicts(t *testing.T) {
+	var dep, conflict fields.Dependency
+	dep.UnmarshalText([]byte("libbad-dev"))
+	conflict.UnmarshalText([]byte("libbad-dev"))
+
+	control := pkg.Control{DebSrc: pkg.SourcePackage{
+		Name:           "hello",
+		BuildDepends:   fields.Dependencies{dep},
+		BuildConflicts: fields.Dependencies{conflict},
+	}}
+
+	solver := resolver.NewBuildDepsSolver(universe(t, buildPackages))
+	if _, err := solver.SolveControl(control, repo.BuildOptions{BuildArch: fields.MakeArch("amd64")}); err == nil ||
+		!strings.Contains(err.Error(), "hello build conflicts with libbad-dev=1:amd64") {
+		t.Fatal(err)
+	}
+}
+
+func TestSolveUndetermined(t *testing.T) {
+	var dep fields.Dependency
+	dep.UnmarshalText([]byte("libfoo-dev"))
+
+	src := repo.SourceIndexItem{Name: "hello", Version: version("1.0-1"), BuildDepends: fields.Dependencies{dep}}
+
+	solver := resolver.NewBuildDepsSolver(universe(t, buildPackages))
+	solver.MaxSteps = 1
+
+	_, err := solver.SolveSource(src, repo.BuildOptions{BuildArch: fields.MakeArch("amd64")})
+
+	var ue *resolver.UnsatisfiableError
+	if !errors.Is(err, resolver.ErrUndetermined) || errors.As(err, &ue) {
+		t.Fatalf("expected ErrUndetermined, got %v", err)
+	}
+}
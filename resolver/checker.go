@@ -0,0 +1,134 @@
+package resolver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/repo"
+)
+
+// DefaultMaxSteps limits the number of candidates tried while checking a single package
+const DefaultMaxSteps = 100000
+
+/*
+Checker reports whether binary packages of a [Universe] are installable on the given architecture, like
+dose-distcheck does.
+
+A package is installable if there is a set of packages, which includes the package itself and all essential packages,
+satisfies every Depends and Pre-Depends of its members and has no Conflicts or Breaks between them. Alternatives and
+virtual packages (Provides) are taken into account, Recommends and Suggests are not.
+*/
+type Checker struct {
+	Universe *Universe
+	Arch     fields.Architecture
+
+	// search gives up after trying that many candidates, [DefaultMaxSteps] if zero
+	MaxSteps int
+}
+
+// NewChecker returns checker of packages for the native architecture arch
+func NewChecker(u *Universe, arch fields.Architecture) *Checker {
+	return &Checker{Universe: u, Arch: arch}
+}
+
+// Result of the installability check of a single package
+type Result struct {
+	Package     repo.BinaryIndexItem
+	Installable bool
+
+	// search limit was reached before the answer was found, the package is neither known to be installable nor not
+	Undetermined bool
+
+	// one of possible installation sets, if the package is installable
+	Installation []repo.BinaryIndexItem
+
+	// human-readable chain of reasons, if the package is not installable, i.e.
+	//
+	//	foo=1.0-1:amd64 depends on bar (>= 2.0)
+	//	no package satisfies bar (>= 2.0)
+	Explanation []string
+}
+
+// [pkg/fmt.Stringer] interface implementation
+func (r Result) String() string {
+	name := fmt.Sprintf("%s=%s:%s", r.Package.Name, r.Package.Version, r.Package.Architecture)
+	if r.Installable {
+		return name + ": installable"
+	}
+	if r.Undetermined {
+		return name + ": undetermined\n  " + strings.Join(r.Explanation, "\n  ")
+	}
+
+	return name + ": not installable\n  " + strings.Join(r.Explanation, "\n  ")
+}
+
+// Results of [Checker.CheckAll]
+type Results []Result
+
+// CheckAll checks every package of the native architecture and `Architecture: all` packages of the universe
+func (c *Checker) CheckAll() Results {
+	var res Results
+
+	all := fields.MakeArch("all")
+	for idx, pkg := range c.Universe.packages {
+		if pkg.Architecture.Equals(c.Arch) || pkg.Architecture == all {
+			res = append(res, c.check(idx))
+		}
+	}
+
+	return res
+}
+
+// Uninstallable returns results for packages, which are not installable. Undetermined ones are not included
+func (r Results) Uninstallable() Results {
+	return r.filter(func(r Result) bool { return !r.Installable && !r.Undetermined })
+}
+
+// Undetermined returns results for packages, which were not checked to the end because of MaxSteps limit
+func (r Results) Undetermined() Results {
+	return r.filter(func(r Result) bool { return r.Undetermined })
+}
+
+func (r Results) filter(keep func(Result) bool) Results {
+	var res Results
+	for _, result := range r {
+		if keep(result) {
+			res = append(res, result)
+		}
+	}
+	return res
+}
+
+// Check checks a single package. It is not installable if it is not a part of the universe
+func (c *Checker) Check(name string, version fields.Version, arch fields.Architecture) Result {
+	for _, idx := range c.Universe.byName[name] {
+		pkg := c.Universe.packages[idx]
+		if pkg.Version.Compare(version) == fields.VersionCompareResultEquals && pkg.Architecture.Equals(arch) {
+			return c.check(idx)
+		}
+	}
+
+	return Result{
+		Package:     repo.BinaryIndexItem{Name: name, Version: version, Architecture: arch},
+		Explanation: []string{fmt.Sprintf("%s=%s:%s is not available", name, version, arch)},
+	}
+}
+
+func (c *Checker) check(idx int) Result {
+	s := newSearch(c.Universe, c.Arch, c.MaxSteps)
+	s.add(idx, nil)
+
+	goals := append(s.essentialGoals(), s.goalsOf(idx)...)
+
+	res := Result{Package: c.Universe.packages[idx]}
+	if f := s.solve(goals); f != nil {
+		res.Explanation = f.explanation
+		res.Undetermined = f.exhausted
+		return res
+	}
+
+	res.Installable = true
+	res.Installation = s.installation()
+	return res
+}
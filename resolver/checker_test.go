@@ -0,0 +1,207 @@
+package resolver_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/repo"
+	"github.com/aol-nnov/debian/resolver"
+)
+
+const packages = `Package: base-files
+Version: 13
+Architecture: amd64
+Essential: yes
+
+Package: foo
+Version: 1.0-1
+Architecture: amd64
+Depends: bar (>= 2.0)
+
+Package: bar
+Version: 1.5-1
+Architecture: amd64
+
+Package: web
+Version: 1
+Architecture: all
+Depends: httpd | nginx
+
+Package: apache2
+Version: 2.4
+Architecture: amd64
+Provides: httpd
+Conflicts: base-files
+
+Package: nginx
+Version: 1.22
+Architecture: amd64
+
+Package: evil
+Version: 1
+Architecture: amd64
+Conflicts: base-files (<< 14)
+
+Package: app
+Version: 1
+Architecture: amd64
+Depends: liba, libb
+
+Package: liba
+Version: 1
+Architecture: amd64
+Breaks: libb (<< 2)
+
+Package: libb
+Version: 1
+Architecture: amd64
+`
+
+func universe(t testing.TB, indices ...string) *resolver.Universe {
+	var res []*repo.BinaryIndex
+	for _, in := range indices {
+		index, err := repo.NewBinaryIndex(strings.NewReader(in), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, index)
+	}
+
+	return resolver.NewUniverse(res...)
+}
+
+func ExampleResults_Uninstallable() {
+	index, _ := repo.NewBinaryIndex(strings.NewReader(packages), nil)
+	checker := resolver.NewChecker(resolver.NewUniverse(index), fields.MakeArch("amd64"))
+
+	for _, res := range checker.CheckAll().Uninstallable() {
+		fmt.Println(res)
+	}
+
+	// Output:
+	// foo=1.0-1:amd64: not installable
+	//   foo=1.0-1:amd64 depends on bar (>= 2.0)
+	//   no package satisfies bar (>= 2.0)
+	// apache2=2.4:amd64: not installable
+	//   base-files is essential
+	//   apache2=2.4:amd64 conflicts with base-files=13:amd64 (base-files)
+	// evil=1:amd64: not installable
+	//   base-files is essential
+	//   evil=1:amd64 conflicts with base-files=13:amd64 (base-files (<< 14))
+	// app=1:amd64: not installable
+	//   app=1:amd64 depends on libb
+	//   liba=1:amd64 breaks libb=1:amd64 (libb (<< 2))
+}
+
+func TestCheckerAlternatives(t *testing.T) {
+	checker := resolver.NewChecker(universe(t, packages), fields.MakeArch("amd64"))
+
+	res := checker.Check("web", version("1"), fields.MakeArch("all"))
+	if !res.Installable {
+		t.Fatal(res)
+	}
+
+	var names []string
+	for _, pkg := range res.Installation {
+		names = append(names, pkg.Name)
+	}
+	if strings.Join(names, " ") != "base-files nginx web" {
+		t.Fatalf("unexpected installation set %v", names)
+	}
+}
+
+func TestCheckerBacktracking(t *testing.T) {
+	// the newest libfoo needs libbar which conflicts with tool, older libfoo is fine
+	checker := resolver.NewChecker(universe(t, `Package: tool
+Version: 1
+Architecture: amd64
+Depends: libfoo
+
+Package: libfoo
+Version: 2
+Architecture: amd64
+Depends: libbar
+
+Package: libbar
+Version: 1
+Architecture: amd64
+Conflicts: tool
+`, `Package: libfoo
+Version: 1
+Architecture: amd64
+`), fields.MakeArch("amd64"))
+
+	res := checker.Check("tool", version("1"), fields.MakeArch("amd64"))
+	if !res.Installable || len(res.Installation) != 2 || res.Installation[0].Version.String() != "1" {
+		t.Fatal(res, res.Installation)
+	}
+}
+
+func TestCheckerMultiArch(t *testing.T) {
+	checker := resolver.NewChecker(universe(t, `Package: app
+Version: 1
+Architecture: amd64
+Depends: libc6, libc6:i386
+
+Package: libc6
+Version: 2.36
+Architecture: amd64
+Multi-Arch: same
+`, `Package: libc6
+Version: 2.36
+Architecture: i386
+Multi-Arch: same
+`), fields.MakeArch("amd64"))
+
+	if res := checker.Check("app", version("1"), fields.MakeArch("amd64")); !res.Installable {
+		t.Fatal(res)
+	}
+}
+
+func TestCheckerConflictsAny(t *testing.T) {
+	checker := resolver.NewChecker(universe(t, `Package: app
+Version: 1
+Architecture: amd64
+Depends: make
+Conflicts: make:any
+
+Package: make
+Version: 4.3
+Architecture: amd64
+Multi-Arch: foreign
+`), fields.MakeArch("amd64"))
+
+	res := checker.Check("app", version("1"), fields.MakeArch("amd64"))
+	if res.Installable || !strings.Contains(res.String(), "app=1:amd64 conflicts with make=4.3:amd64") {
+		t.Fatalf("conflict with :any must match a package without Multi-Arch: allowed, got %v", res)
+	}
+}
+
+func version(s string) fields.Version {
+	var v fields.Version
+	v.UnmarshalText([]byte(s))
+	return v
+}
+
+func TestCheckerUndetermined(t *testing.T) {
+	checker := resolver.NewChecker(universe(t, packages), fields.MakeArch("amd64"))
+	checker.MaxSteps = 1
+
+	res := checker.Check("app", version("1"), fields.MakeArch("amd64"))
+	if res.Installable || !res.Undetermined || !strings.HasPrefix(res.String(), "app=1:amd64: undetermined") {
+		t.Fatalf("unexpected result %v", res)
+	}
+
+	results := checker.CheckAll()
+	for _, res := range results.Uninstallable() {
+		if res.Undetermined {
+			t.Fatalf("undetermined package reported as uninstallable: %v", res)
+		}
+	}
+
+	if len(results.Undetermined()) == 0 {
+		t.Fatal("no undetermined packages reported")
+	}
+}
@@ -0,0 +1,277 @@
+package resolver
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/repo"
+)
+
+// dependency to be satisfied by the installation set
+type goal struct {
+	dep      fields.Dependency
+	arch     fields.Architecture // architecture the dependency is declared for
+	from     int                 // index of the depending package, -1 if it is not a package
+	root     string              // what depends, if it is not a package, empty for essential packages
+	relation string              // "depends on", "pre-depends on", etc.
+}
+
+// reason the goal could not be satisfied
+type failure struct {
+	explanation []string
+	exhausted   bool // search limit reached, no point to try further
+}
+
+// backtracking search of an installation set
+type search struct {
+	u        *Universe
+	arch     fields.Architecture
+	maxSteps int
+	steps    int
+
+	selected []int
+	reason   map[int]*goal // why the package was selected, nil for the initial ones
+
+	conflicts []goal // relations, which must not be satisfied by any selected package, i.e. Build-Conflicts
+}
+
+func newSearch(u *Universe, arch fields.Architecture, maxSteps int) *search {
+	if maxSteps == 0 {
+		maxSteps = DefaultMaxSteps
+	}
+
+	return &search{
+		u:        u,
+		arch:     arch,
+		maxSteps: maxSteps,
+		reason:   make(map[int]*goal),
+	}
+}
+
+func (s *search) add(idx int, why *goal) {
+	s.selected = append(s.selected, idx)
+	s.reason[idx] = why
+}
+
+// removes the most recently added package
+func (s *search) pop() {
+	idx := s.selected[len(s.selected)-1]
+	s.selected = s.selected[:len(s.selected)-1]
+	delete(s.reason, idx)
+}
+
+// packages selected so far, sorted by name
+func (s *search) installation() []repo.BinaryIndexItem {
+	res := make([]repo.BinaryIndexItem, 0, len(s.selected))
+	for _, idx := range s.selected {
+		res = append(res, s.u.packages[idx])
+	}
+
+	slices.SortFunc(res, func(a, b repo.BinaryIndexItem) int { return cmp.Compare(a.Name, b.Name) })
+	return res
+}
+
+// architecture dependencies of the package are declared for
+func (s *search) hostArch(idx int) fields.Architecture {
+	if arch := s.u.packages[idx].Architecture; arch != fields.MakeArch("all") {
+		return arch
+	}
+	return s.arch
+}
+
+// every essential package of the native architecture must be installed
+func (s *search) essentialGoals() []goal {
+	var res []goal
+
+	all := fields.MakeArch("all")
+	seen := make(map[string]bool)
+	for _, idx := range s.u.essential {
+		pkg := s.u.packages[idx]
+		if seen[pkg.Name] || !(pkg.Architecture.Equals(s.arch) || pkg.Architecture == all) {
+			continue
+		}
+		seen[pkg.Name] = true
+
+		res = append(res, goal{dep: fields.Dependency{Name: pkg.Name}, arch: s.arch, from: -1})
+	}
+
+	return res
+}
+
+func (s *search) goalsOf(idx int) []goal {
+	pkg := s.u.packages[idx]
+
+	res := make([]goal, 0, len(pkg.PreDepends)+len(pkg.Depends))
+	for _, dep := range pkg.PreDepends {
+		res = append(res, goal{dep: dep, arch: s.hostArch(idx), from: idx, relation: "pre-depends on"})
+	}
+	for _, dep := range pkg.Depends {
+		res = append(res, goal{dep: dep, arch: s.hostArch(idx), from: idx, relation: "depends on"})
+	}
+
+	return res
+}
+
+// true if some selected package satisfies g already
+func (s *search) satisfied(g goal) bool {
+	return slices.ContainsFunc(s.selected, func(idx int) bool {
+		return g.dep.SatisfiedBy(s.u.candidates[idx], s.arch, g.arch)
+	})
+}
+
+// packages satisfying g in order of preference: alternatives as listed, newer versions first, native architecture first
+func (s *search) candidates(g goal) []int {
+	var res []int
+	for alt := &g.dep; alt != nil; alt = alt.Alt {
+		single := *alt
+		single.Alt = nil
+
+		var found []int
+		for idx := range s.u.lookup(alt.Name) {
+			if !slices.Contains(res, idx) && !slices.Contains(found, idx) &&
+				single.SatisfiedBy(s.u.candidates[idx], s.arch, g.arch) {
+				found = append(found, idx)
+			}
+		}
+
+		slices.SortStableFunc(found, func(a, b int) int {
+			if c := s.u.packages[b].Version.Compare(s.u.packages[a].Version); c != fields.VersionCompareResultEquals {
+				return int(c)
+			}
+			return cmp.Compare(s.archRank(a), s.archRank(b))
+		})
+
+		res = append(res, found...)
+	}
+
+	return res
+}
+
+func (s *search) archRank(idx int) int {
+	if s.u.packages[idx].Architecture.Equals(s.arch) {
+		return 0
+	}
+	return 1
+}
+
+// returns description of a conflict between the candidate and the selected packages, empty if there is none
+func (s *search) conflict(idx int) string {
+	for _, c := range s.conflicts {
+		if c.dep.ConflictsWith(s.u.candidates[idx], s.arch, c.arch) {
+			return fmt.Sprintf("%s %s %s (%s)", c.root, c.relation, s.u.describe(idx), c.dep)
+		}
+	}
+
+	for _, other := range s.selected {
+		if reason := s.conflictBetween(idx, other); reason != "" {
+			return reason
+		}
+		if reason := s.conflictBetween(other, idx); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// checks Conflicts and Breaks of a against b
+func (s *search) conflictBetween(a, b int) string {
+	pa, pb := s.u.packages[a], s.u.packages[b]
+
+	if pa.Name == pb.Name {
+		if !pa.Architecture.Equals(pb.Architecture) &&
+			pa.MultiArch == fields.MultiArchSame && pb.MultiArch == fields.MultiArchSame {
+			return ""
+		}
+		return fmt.Sprintf("%s and %s can not be installed at the same time", s.u.describe(b), s.u.describe(a))
+	}
+
+	for _, relation := range []struct {
+		name string
+		deps fields.Dependencies
+	}{{"conflicts with", pa.Conflicts}, {"breaks", pa.Breaks}} {
+		for _, dep := range relation.deps {
+			if dep.ConflictsWith(s.u.candidates[b], s.arch, s.hostArch(a)) {
+				return fmt.Sprintf("%s %s %s (%s)", s.u.describe(a), relation.name, s.u.describe(b), dep)
+			}
+		}
+	}
+
+	return ""
+}
+
+// chain of dependencies, which lead to the goal
+func (s *search) chain(g *goal) []string {
+	var res []string
+	for g != nil {
+		if g.from < 0 && g.root == "" {
+			res = append(res, fmt.Sprintf("%s is essential", g.dep.Name))
+			break
+		}
+
+		if g.from < 0 {
+			res = append(res, fmt.Sprintf("%s %s %s", g.root, g.relation, g.dep))
+			break
+		}
+
+		res = append(res, fmt.Sprintf("%s %s %s", s.u.describe(g.from), g.relation, g.dep))
+		g = s.reason[g.from]
+	}
+
+	slices.Reverse(res)
+	return res
+}
+
+// satisfies goals one by one, backtracking on failure. Selected packages are left in place on success
+func (s *search) solve(goals []goal) *failure {
+	for len(goals) > 0 && s.satisfied(goals[0]) {
+		goals = goals[1:]
+	}
+	if len(goals) == 0 {
+		return nil
+	}
+
+	g, rest := goals[0], goals[1:]
+
+	candidates := s.candidates(g)
+	if len(candidates) == 0 {
+		return &failure{explanation: append(s.chain(&g), fmt.Sprintf("no package satisfies %s", g.dep))}
+	}
+
+	var first *failure
+	for _, idx := range candidates {
+		if s.steps++; s.steps > s.maxSteps {
+			return &failure{
+				explanation: []string{fmt.Sprintf("gave up after %d steps", s.maxSteps)},
+				exhausted:   true,
+			}
+		}
+
+		if reason := s.conflict(idx); reason != "" {
+			if first == nil {
+				first = &failure{explanation: append(s.chain(&g), reason)}
+			}
+			continue
+		}
+
+		s.add(idx, &g)
+
+		// dependencies of the candidate go first, so the search goes deep before going wide
+		f := s.solve(append(s.goalsOf(idx), rest...))
+		if f == nil {
+			return nil
+		}
+
+		s.pop()
+
+		if f.exhausted {
+			return f
+		}
+		if first == nil {
+			first = f
+		}
+	}
+
+	return first
+}
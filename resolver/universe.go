@@ -0,0 +1,83 @@
+/*
+Package resolver checks installability of binary packages and computes installation sets, much like dose-distcheck
+and sbuild resolver do.
+
+Packages of one or more [repo.BinaryIndex]es are merged into a [Universe], which is then queried by [Checker] and
+[BuildDepsSolver].
+*/
+package resolver
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/aol-nnov/debian/fields"
+	"github.com/aol-nnov/debian/repo"
+)
+
+// Universe is a set of binary packages, which may be installed
+type Universe struct {
+	packages   []repo.BinaryIndexItem
+	candidates []fields.Candidate
+
+	byName     map[string][]int
+	byProvides map[string][]int // providers of virtual packages
+	essential  []int
+}
+
+// NewUniverse merges binary indices. The first occurrence of a package (name, version, architecture) wins
+func NewUniverse(indices ...*repo.BinaryIndex) *Universe {
+	u := Universe{
+		byName:     make(map[string][]int),
+		byProvides: make(map[string][]int),
+	}
+
+	seen := make(map[string]bool)
+	for _, index := range indices {
+		for _, pkg := range index.Items {
+			key := fmt.Sprintf("%s=%s:%s", pkg.Name, pkg.Version, pkg.Architecture)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			idx := len(u.packages)
+			u.packages = append(u.packages, pkg)
+			u.candidates = append(u.candidates, pkg.Candidate())
+			u.byName[pkg.Name] = append(u.byName[pkg.Name], idx)
+			if pkg.Essential {
+				u.essential = append(u.essential, idx)
+			}
+
+			for _, provided := range pkg.Provides {
+				u.byProvides[provided.Name] = append(u.byProvides[provided.Name], idx)
+			}
+		}
+	}
+
+	return &u
+}
+
+// Packages returns all packages of the universe
+func (u *Universe) Packages() []repo.BinaryIndexItem {
+	return u.packages
+}
+
+// indices of packages, which provide name either as real or virtual package
+func (u *Universe) lookup(name string) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for _, indices := range [...][]int{u.byName[name], u.byProvides[name]} {
+			for _, idx := range indices {
+				if !yield(idx) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// name=version:arch form of a package, like apt-get install accepts
+func (u *Universe) describe(idx int) string {
+	pkg := u.packages[idx]
+	return fmt.Sprintf("%s=%s:%s", pkg.Name, pkg.Version, pkg.Architecture)
+}